@@ -0,0 +1,171 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Batch sends queued queries and commands to the database in one round-trip.
+// Destinations are filled when Exec is called.
+type Batch interface {
+	AddQuery(q Query) Batch
+	AddCommand(c Command) Batch
+	Exec(ctx context.Context) error
+}
+
+type batch struct {
+	client *client
+	items  []*batchItem
+}
+
+type batchItem struct {
+	query   *query
+	command *command
+	err     error
+}
+
+func (b *batch) AddQuery(q Query) Batch {
+	item := &batchItem{}
+
+	item.query, _ = q.(*query)
+	if item.query == nil {
+		item.err = errors.New("query must be created by client")
+	}
+
+	b.items = append(b.items, item)
+
+	return b
+}
+
+func (b *batch) AddCommand(c Command) Batch {
+	item := &batchItem{}
+
+	item.command, _ = c.(*command)
+	if item.command == nil {
+		item.err = errors.New("command must be created by client")
+	}
+
+	b.items = append(b.items, item)
+
+	return b
+}
+
+// Exec sends the batch in the transaction from ctx, or in an implicit transaction.
+// If any item fails, *BatchError with the errors of all items is returned.
+func (b *batch) Exec(ctx context.Context) error {
+	pgxBatch := &pgx.Batch{}
+
+	errs := make([]error, len(b.items))
+	dests := make([]reflect.Value, len(b.items))
+	statements := make([][]statement, len(b.items))
+
+	for i, item := range b.items {
+		if item.err != nil {
+			errs[i] = item.err
+			continue
+		}
+
+		if item.query != nil {
+			dest, err := item.query.getDest()
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+
+			sql, sqlArgs, err := item.query.build(dest)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+
+			dests[i] = dest
+			statements[i] = []statement{{sql: sql, args: sqlArgs}}
+		} else {
+			sts, err := item.command.build()
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+
+			statements[i] = sts
+		}
+
+		for _, st := range statements[i] {
+			pgxBatch.Queue(st.sql, st.args...)
+		}
+	}
+
+	if pgxBatch.Len() != 0 {
+		results := b.client.getQueryManager(ctx).SendBatch(ctx, pgxBatch)
+
+		b.readResults(ctx, results, dests, statements, errs)
+
+		err := results.Close()
+		if err != nil && !slices.ContainsFunc(errs, func(e error) bool { return e != nil }) {
+			return wrapError(err)
+		}
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return &BatchError{Errors: errs}
+		}
+	}
+
+	return nil
+}
+
+// readResults reads the results of the items in the order they were queued.
+// Results which are left by a failed command are skipped, so the next items get their own results.
+func (b *batch) readResults(ctx context.Context, results pgx.BatchResults, dests []reflect.Value, statements [][]statement, errs []error) {
+	r := &batchRunner{results: results}
+
+	for i, item := range b.items {
+		if errs[i] != nil {
+			continue
+		}
+
+		if item.query != nil {
+			rows, err := results.Query()
+			if err != nil {
+				errs[i] = wrapError(err)
+				continue
+			}
+
+			errs[i] = b.client.mapRowsToDest(rows, dests[i], item.query.sql)
+
+			continue
+		}
+
+		r.read = 0
+		_, errs[i] = item.command.run(ctx, r, statements[i])
+
+		for range len(statements[i]) - r.read {
+			_, _ = results.Exec()
+		}
+	}
+}
+
+// batchRunner reads the results of the queued statements in order, so sql and args are ignored.
+type batchRunner struct {
+	results pgx.BatchResults
+	// read is the number of results read by the current command.
+	read int
+}
+
+func (r *batchRunner) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	r.read++
+
+	return r.results.Exec()
+}
+
+func (r *batchRunner) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	r.read++
+
+	return r.results.Query()
+}
@@ -0,0 +1,94 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeBatchResults returns the queued rows in order, like the results of pgx.Batch.
+type fakeBatchResults struct {
+	rows []*fakeRows
+	read int
+}
+
+func (r *fakeBatchResults) next() (*fakeRows, error) {
+	if r.read >= len(r.rows) {
+		return nil, errors.New("no result")
+	}
+
+	r.read++
+
+	return r.rows[r.read-1], nil
+}
+
+func (r *fakeBatchResults) Exec() (pgconn.CommandTag, error) {
+	rows, err := r.next()
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	return rows.CommandTag(), nil
+}
+
+func (r *fakeBatchResults) Query() (pgx.Rows, error) {
+	return r.next()
+}
+
+func (r *fakeBatchResults) QueryRow() pgx.Row {
+	panic("not used")
+}
+
+func (r *fakeBatchResults) Close() error {
+	return nil
+}
+
+func TestBatchSkipsResultsOfFailedCommand(t *testing.T) {
+	c := &client{}
+
+	var returned []valuesUser
+	var selected valuesUser
+
+	b := &batch{
+		client: c,
+		items: []*batchItem{
+			{command: &command{client: c, sql: "INSERT", dest: reflect.ValueOf(&returned), withReturning: true}},
+			{query: &query{client: c, sql: "SELECT"}},
+		},
+	}
+
+	results := &fakeBatchResults{rows: []*fakeRows{
+		// The first chunk of the command fails to map, the second one must be skipped.
+		newFakeRows(t, 1, fakeColumn{"id", pgtype.TextOID, "abc"}),
+		newFakeRows(t, 1, fakeColumn{"id", pgtype.Int8OID, int64(2)}),
+		newFakeRows(t, 1, fakeColumn{"id", pgtype.Int8OID, int64(42)}, fakeColumn{"name", pgtype.TextOID, "q"}),
+	}}
+
+	dests := []reflect.Value{{}, reflect.ValueOf(&selected).Elem()}
+	statements := [][]statement{{{sql: "INSERT 1"}, {sql: "INSERT 2"}}, {{sql: "SELECT"}}}
+	errs := make([]error, 2)
+
+	b.readResults(context.Background(), results, dests, statements, errs)
+
+	var scanErr *ScanError
+	if !errors.As(errs[0], &scanErr) {
+		t.Errorf("command error = %v, want *ScanError", errs[0])
+	}
+
+	if errs[1] != nil {
+		t.Fatalf("query error = %v", errs[1])
+	}
+
+	if want := (valuesUser{ID: 42, Name: "q"}); selected != want {
+		t.Errorf("query dest = %+v, want %+v", selected, want)
+	}
+
+	if results.read != 3 {
+		t.Errorf("read %d results, want 3", results.read)
+	}
+}
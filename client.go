@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"sync"
 
@@ -16,7 +17,14 @@ import (
 type Client interface {
 	Query(sql string, dest any) Query
 	Command(sql string, src any) Command
+	Cursor(sql string, dest any) Cursor
+	Batch() Batch
 	Transactional(ctx context.Context, fn TxFunc, opts ...TxOption) error
+	Begin(ctx context.Context, opts ...TxOption) (Tx, context.Context, error)
+	CopyFrom(ctx context.Context, table string, src any, columns ...string) (int64, error)
+	CopyToWriter(ctx context.Context, w io.Writer, sql string, src any, opts ...CopyOption) (int64, error)
+	CopyFromReader(ctx context.Context, r io.Reader, table string, columns []string, opts ...CopyOption) (int64, error)
+	Register(models ...any) error
 
 	ToPgx() *pgxpool.Pool
 	ToDB() *sql.DB
@@ -51,15 +59,14 @@ func New(ctx context.Context, cfg *Config) (Client, error) {
 	}
 
 	return &client{
-		pool:   pool,
-		models: make(map[reflect.Type]*parsedModel),
+		pool: pool,
 	}, nil
 }
 
 type client struct {
-	pool    *pgxpool.Pool
-	models  map[reflect.Type]*parsedModel
-	modelMu sync.Mutex
+	pool *pgxpool.Pool
+	// models holds *parsedModel by reflect.Type.
+	models sync.Map
 }
 
 func (c *client) Query(sql string, dest any) Query {
@@ -81,20 +88,61 @@ func (c *client) Command(sql string, src any) Command {
 	}
 }
 
+func (c *client) Cursor(sql string, dest any) Cursor {
+	return &cursor{
+		client:    c,
+		sql:       sql,
+		dest:      reflect.ValueOf(dest),
+		args:      make(map[string]any),
+		batchSize: defaultCursorBatchSize,
+	}
+}
+
+func (c *client) Batch() Batch {
+	return &batch{
+		client: c,
+	}
+}
+
 func (c *client) Transactional(ctx context.Context, fn TxFunc, opts ...TxOption) error {
-	tx, err := c.pool.BeginTx(ctx, *getTxOptions(opts))
+	options := getTxOptions(opts)
+
+	if InTx(ctx) {
+		return c.runTx(ctx, fn, options)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.runTx(ctx, fn, options)
+		if err == nil || attempt >= options.retry.attempts || !isRetryable(err) {
+			return err
+		}
+
+		if options.retry.hook != nil {
+			options.retry.hook(attempt, err)
+		}
+
+		if sleepErr := sleep(ctx, options.retry.delay(attempt)); sleepErr != nil {
+			return errors.Join(err, sleepErr)
+		}
+	}
+}
+
+func (c *client) runTx(ctx context.Context, fn TxFunc, opts *txOptions) error {
+	tx, err := c.beginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
-	tc := &txContext{
-		tx:   tx,
-		base: ctx,
-	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			panic(p)
+		}
+	}()
 
-	if err := fn(tc); err != nil {
-		if err := tx.Rollback(ctx); err != nil {
-			return err
+	if err := fn(withTx(ctx, tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return errors.Join(err, rbErr)
 		}
 
 		return err
@@ -107,6 +155,63 @@ func (c *client) Transactional(ctx context.Context, fn TxFunc, opts ...TxOption)
 	return nil
 }
 
+// Begin starts a transaction that must be finished with Tx.Commit or Tx.Rollback.
+// The returned context must be passed to Query and Command to run them inside the transaction.
+// Retry options are ignored, because there is no func to re-run.
+func (c *client) Begin(ctx context.Context, opts ...TxOption) (Tx, context.Context, error) {
+	tx, err := c.beginTx(ctx, getTxOptions(opts))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tx, withTx(ctx, tx), nil
+}
+
+// beginTx starts a new transaction, or a savepoint if ctx already holds one.
+// Options are ignored for savepoints, since they inherit the outer transaction settings.
+func (c *client) beginTx(ctx context.Context, opts *txOptions) (*transaction, error) {
+	var tx pgx.Tx
+	var err error
+
+	parent, ok := getTransaction(ctx)
+	if ok {
+		tx, err = parent.tx.Begin(ctx)
+	} else {
+		tx, err = c.pool.BeginTx(ctx, opts.pgx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction{tx: tx, parent: parent}, nil
+}
+
+func (c *client) getQueryManager(ctx context.Context) queryManager {
+	tx, ok := TxFromContext(ctx)
+	if ok {
+		return tx
+	}
+
+	return c.pool
+}
+
+// withConn calls fn with the connection of the transaction from ctx, or with a connection from the pool.
+func (c *client) withConn(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	tx, ok := TxFromContext(ctx)
+	if ok {
+		return fn(tx.Conn())
+	}
+
+	conn, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return fn(conn.Conn())
+}
+
 func (c *client) ToPgx() *pgxpool.Pool {
 	return c.pool
 }
@@ -119,47 +224,63 @@ func (c *client) Close() {
 	c.pool.Close()
 }
 
-func (c *client) registerModel(modelType reflect.Type) error {
-	_, ok := c.models[modelType]
-	if ok {
-		return nil
+// Register parses and validates the models, so invalid models fail at startup instead of the first query.
+// Models can be passed as values or pointers.
+func (c *client) Register(models ...any) error {
+	for _, m := range models {
+		modelType := reflect.TypeOf(m)
+		if modelType == nil {
+			return errors.New("model must not be nil")
+		}
+
+		if modelType.Kind() == reflect.Pointer {
+			modelType = modelType.Elem()
+		}
+
+		if !isModelType(modelType) {
+			return fmt.Errorf("model must be struct, got %s", modelType)
+		}
+
+		err := c.registerModel(modelType)
+		if err != nil {
+			return err
+		}
 	}
 
-	c.modelMu.Lock()
-	defer c.modelMu.Unlock()
+	return nil
+}
+
+func (c *client) registerModel(modelType reflect.Type) error {
+	_, err := c.getModel(modelType)
+
+	return err
+}
 
-	_, ok = c.models[modelType]
+func (c *client) getModel(modelType reflect.Type) (*parsedModel, error) {
+	model, ok := c.models.Load(modelType)
 	if ok {
-		return nil
+		return model.(*parsedModel), nil
 	}
 
 	fields, err := parseModel(modelType)
 	if err != nil {
-		return err
-	}
-
-	model := &parsedModel{
-		fields:  fields,
-		queries: make(map[string]sqlFunc),
+		return nil, err
 	}
 
-	c.models[modelType] = model
+	model, _ = c.models.LoadOrStore(modelType, &parsedModel{fields: fields})
 
-	return nil
+	return model.(*parsedModel), nil
 }
 
 func (c *client) getSqlFunc(modelType reflect.Type, sql string) (sqlFunc, error) {
-	fn, ok := c.models[modelType].queries[sql]
-	if ok {
-		return fn, nil
+	model, err := c.getModel(modelType)
+	if err != nil {
+		return nil, err
 	}
 
-	c.modelMu.Lock()
-	defer c.modelMu.Unlock()
-
-	fn, ok = c.models[modelType].queries[sql]
+	fn, ok := model.queries.Load(sql)
 	if ok {
-		return fn, nil
+		return fn.(sqlFunc), nil
 	}
 
 	parsedSql, keys, err := extractKeys(sql)
@@ -167,86 +288,95 @@ func (c *client) getSqlFunc(modelType reflect.Type, sql string) (sqlFunc, error)
 		return nil, err
 	}
 
-	fn = getSqlFunc(parsedSql, keys, c.models[modelType].fields)
-	c.models[modelType].queries[sql] = fn
+	fn, _ = model.queries.LoadOrStore(sql, getSqlFunc(sql, parsedSql, keys, model.fields))
 
-	return fn, nil
+	return fn.(sqlFunc), nil
 }
 
-func (c *client) mapRowsToDest(rows pgx.Rows, dest reflect.Value) error {
-	if dest.Kind() == reflect.Struct {
-		rowsCount := 0
-		setters := c.models[dest.Type()].fields.setters
-		for rows.Next() {
-			if rowsCount > 0 {
-				return errors.New("to many values")
-			}
+func (c *client) getValuesSql(modelType reflect.Type, sql string) (*valuesSql, error) {
+	model, err := c.getModel(modelType)
+	if err != nil {
+		return nil, err
+	}
 
-			values, err := rows.Values()
-			if err != nil {
-				return err
-			}
+	vs, ok := model.multiQueries.Load(sql)
+	if ok {
+		return vs.(*valuesSql), nil
+	}
 
-			descriptions := rows.FieldDescriptions()
+	parsed, err := parseValuesSql(sql)
+	if err != nil {
+		return nil, err
+	}
 
-			for i := range descriptions {
-				column := descriptions[i].Name
+	vs, _ = model.multiQueries.LoadOrStore(sql, parsed)
 
-				setter, ok := setters[column]
-				if !ok {
-					continue
-				}
+	return vs.(*valuesSql), nil
+}
 
-				err = setter(dest, reflect.ValueOf(values[i]))
-				if err != nil {
-					return err
-				}
-			}
+func (c *client) getModelFields(modelType reflect.Type) (*modelFields, error) {
+	model, err := c.getModel(modelType)
+	if err != nil {
+		return nil, err
+	}
 
-			rowsCount++
-		}
+	return model.fields, nil
+}
 
-		if rowsCount == 0 {
-			return errors.New("not found value")
-		}
-	} else {
-		modelType := dest.Type().Elem()
-		if dest.Type().Elem().Kind() == reflect.Pointer {
-			modelType = modelType.Elem()
-		}
+// mapRowsToDest fills dest with the rows. A list dest gets a value per row,
+// any other dest must get exactly one row.
+func (c *client) mapRowsToDest(rows pgx.Rows, dest reflect.Value, sql string) error {
+	defer rows.Close()
+
+	modelType := getDestModelType(dest.Type())
+	mapRow := c.newRowMapper(modelType, sql)
+
+	if isListType(dest.Type()) {
+		isPointer := dest.Type().Elem() != modelType
 
-		setters := c.models[modelType].fields.setters
 		for rows.Next() {
+			model := reflect.New(modelType).Elem()
 
-			values, err := rows.Values()
+			err := mapRow(rows, model)
 			if err != nil {
 				return err
 			}
 
-			descriptions := rows.FieldDescriptions()
+			if isPointer {
+				model = model.Addr()
+			}
 
-			model := reflect.New(modelType).Elem()
+			dest.Set(reflect.Append(dest, model))
+		}
 
-			for i := range descriptions {
-				column := descriptions[i].Name
+		if err := rows.Err(); err != nil {
+			return wrapError(err)
+		}
 
-				setter, ok := setters[column]
-				if !ok {
-					continue
-				}
+		return nil
+	}
 
-				err = setter(model, reflect.ValueOf(values[i]))
-				if err != nil {
-					return err
-				}
-			}
+	rowsCount := 0
 
-			if dest.Type().Elem().Kind() == reflect.Pointer {
-				model = model.Addr()
-			}
+	for rows.Next() {
+		if rowsCount > 0 {
+			return ErrTooManyRows
+		}
 
-			dest.Set(reflect.Append(dest, model))
+		err := mapRow(rows, dest)
+		if err != nil {
+			return err
 		}
+
+		rowsCount++
+	}
+
+	if err := rows.Err(); err != nil {
+		return wrapError(err)
+	}
+
+	if rowsCount == 0 {
+		return ErrNotFound
 	}
 
 	return nil
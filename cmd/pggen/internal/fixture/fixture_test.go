@@ -0,0 +1,73 @@
+package fixture
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gosuit/pg/v2"
+)
+
+// TestMapperKeys registers the mapper again, because pg.RegisterMapper panics
+// if its keys and fields don't match the ones pg gets with reflection.
+func TestMapperKeys(t *testing.T) {
+	want := []string{
+		"base.id", "name", "email", "address.city", "address.location.lat", "address.location.lng",
+		"point", "created", "parent_id", "settings.theme",
+	}
+
+	if !slices.Equal(pgMapperUser.Keys, want) {
+		t.Errorf("keys = %v, want %v", pgMapperUser.Keys, want)
+	}
+
+	register := func(m pg.Mapper[User]) (panicked any) {
+		defer func() { panicked = recover() }()
+
+		pg.RegisterMapper(m)
+
+		return nil
+	}
+
+	if p := register(pgMapperUser); p != nil {
+		t.Errorf("generated mapper doesn't match reflection: %v", p)
+	}
+
+	stale := pgMapperUser
+	stale.Keys = slices.Clone(pgMapperUser.Keys)
+	stale.Keys[4], stale.Keys[5] = stale.Keys[5], stale.Keys[4]
+
+	if register(stale) == nil {
+		t.Error("mapper with swapped keys is registered")
+	}
+}
+
+func TestMapperRoundTrip(t *testing.T) {
+	lat := slices.Index(pgMapperUser.Keys, "address.location.lat")
+	name := slices.Index(pgMapperUser.Keys, "name")
+	keys := []int{lat, -1, name}
+
+	var src User
+	src.Name = "admin"
+	src.Address.Location.Lat = 52.5
+
+	args := pgMapperUser.Args(&src, keys, nil)
+	if want := []any{52.5, nil, "admin"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+
+	var dst User
+
+	dests := make([]any, len(keys))
+	pgMapperUser.Targets(&dst, keys, dests)
+
+	if dests[1] != nil {
+		t.Errorf("dest of unknown key = %v, want nil", dests[1])
+	}
+
+	*dests[0].(*float64) = args[0].(float64)
+	*dests[2].(*string) = args[2].(string)
+
+	if dst.Address.Location.Lat != 52.5 || dst.Name != "admin" {
+		t.Errorf("model = %+v", dst)
+	}
+}
@@ -0,0 +1,37 @@
+package geo
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+type Location struct {
+	Lat float64 `pg:"lat"`
+	Lng float64 `pg:"lng"`
+}
+
+type Address struct {
+	City     string `pg:"city"`
+	Location Location
+	zip      string
+}
+
+// Point is scanned as a single value, because it implements sql.Scanner.
+type Point struct {
+	X, Y float64
+}
+
+func (p *Point) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("can`t scan %T into point", src)
+	}
+
+	_, err := fmt.Sscanf(s, "(%g,%g)", &p.X, &p.Y)
+
+	return err
+}
+
+func (p Point) Value() (driver.Value, error) {
+	return fmt.Sprintf("(%g,%g)", p.X, p.Y), nil
+}
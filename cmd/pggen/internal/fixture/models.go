@@ -0,0 +1,38 @@
+package fixture
+
+import (
+	"time"
+
+	"github.com/gosuit/pg/v2/cmd/pggen/internal/fixture/geo"
+)
+
+//go:generate go run github.com/gosuit/pg/v2/cmd/pggen
+
+type Base struct {
+	ID int64 `pg:"id"`
+}
+
+type User struct {
+	Base
+	Name     string      `pg:"name"`
+	Email    *string     `pg:"email"`
+	Address  geo.Address `pg:"address"`
+	Point    geo.Point   `pg:"point"`
+	Created  time.Time
+	Parent   *Base `pg:"parent_id"`
+	Settings struct {
+		Theme string `pg:"theme"`
+	} `pg:"settings"`
+	Skip  string `pg:"-"`
+	cache map[string]any
+}
+
+type Tag struct {
+	UserID int64  `pg:"user_id"`
+	Name   string `pg:"name"`
+}
+
+// Untagged has no pg tags, so it is generated only when requested with -type.
+type Untagged struct {
+	Name string
+}
@@ -0,0 +1,129 @@
+// Code generated by pggen. DO NOT EDIT.
+
+package fixture
+
+import "github.com/gosuit/pg/v2"
+
+func init() {
+	pg.RegisterMapper(pgMapperBase)
+	pg.RegisterMapper(pgMapperTag)
+	pg.RegisterMapper(pgMapperUser)
+}
+
+var pgMapperBase = pg.Mapper[Base]{
+	Keys: []string{"id"},
+	Args: func(m *Base, keys []int, args []any) []any {
+		for _, k := range keys {
+			switch k {
+			case 0:
+				args = append(args, m.ID)
+			default:
+				args = append(args, nil)
+			}
+		}
+
+		return args
+	},
+	Targets: func(m *Base, keys []int, dests []any) {
+		for i, k := range keys {
+			switch k {
+			case 0:
+				dests[i] = &m.ID
+			default:
+				dests[i] = nil
+			}
+		}
+	},
+}
+
+var pgMapperTag = pg.Mapper[Tag]{
+	Keys: []string{"user_id", "name"},
+	Args: func(m *Tag, keys []int, args []any) []any {
+		for _, k := range keys {
+			switch k {
+			case 0:
+				args = append(args, m.UserID)
+			case 1:
+				args = append(args, m.Name)
+			default:
+				args = append(args, nil)
+			}
+		}
+
+		return args
+	},
+	Targets: func(m *Tag, keys []int, dests []any) {
+		for i, k := range keys {
+			switch k {
+			case 0:
+				dests[i] = &m.UserID
+			case 1:
+				dests[i] = &m.Name
+			default:
+				dests[i] = nil
+			}
+		}
+	},
+}
+
+var pgMapperUser = pg.Mapper[User]{
+	Keys: []string{"base.id", "name", "email", "address.city", "address.location.lat", "address.location.lng", "point", "created", "parent_id", "settings.theme"},
+	Args: func(m *User, keys []int, args []any) []any {
+		for _, k := range keys {
+			switch k {
+			case 0:
+				args = append(args, m.Base.ID)
+			case 1:
+				args = append(args, m.Name)
+			case 2:
+				args = append(args, m.Email)
+			case 3:
+				args = append(args, m.Address.City)
+			case 4:
+				args = append(args, m.Address.Location.Lat)
+			case 5:
+				args = append(args, m.Address.Location.Lng)
+			case 6:
+				args = append(args, m.Point)
+			case 7:
+				args = append(args, m.Created)
+			case 8:
+				args = append(args, m.Parent)
+			case 9:
+				args = append(args, m.Settings.Theme)
+			default:
+				args = append(args, nil)
+			}
+		}
+
+		return args
+	},
+	Targets: func(m *User, keys []int, dests []any) {
+		for i, k := range keys {
+			switch k {
+			case 0:
+				dests[i] = &m.Base.ID
+			case 1:
+				dests[i] = &m.Name
+			case 2:
+				dests[i] = &m.Email
+			case 3:
+				dests[i] = &m.Address.City
+			case 4:
+				dests[i] = &m.Address.Location.Lat
+			case 5:
+				dests[i] = &m.Address.Location.Lng
+			case 6:
+				dests[i] = &m.Point
+			case 7:
+				dests[i] = &m.Created
+			case 8:
+				dests[i] = &m.Parent
+			case 9:
+				dests[i] = &m.Settings.Theme
+			default:
+				dests[i] = nil
+			}
+		}
+	},
+}
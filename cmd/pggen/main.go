@@ -0,0 +1,358 @@
+// Pggen generates mappers which bind and scan the fields of the models without reflection.
+//
+// Usage:
+//
+//	//go:generate go run github.com/gosuit/pg/v2/cmd/pggen -type User,Order
+//
+// Keys are built by the same rules as at runtime: the pg tag or the lowercased field name,
+// "-" and unexported fields are skipped, and nested structs are flattened with dots.
+// The package is type-checked, so structs from other packages are flattened too.
+// Each mapper is generated as the pgMapper<Type> variable and registered in init.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const pgTag = "pg"
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("pggen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of type names; defaults to all structs with pg tags")
+	output := flag.String("output", "pg_gen.go", "output file name")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+
+	src, err := generate(dir, *output, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, *output), src, 0o644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type field struct {
+	key  string
+	path string
+}
+
+func generate(dir, output string, names []string) ([]byte, error) {
+	pkg, err := loadPackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		for _, name := range pkg.Scope().Names() {
+			if st, ok := structOf(pkg.Scope().Lookup(name)); ok && hasTags(st) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, errors.New("no types to generate")
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by pggen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg.Name())
+	fmt.Fprintf(&buf, "import \"github.com/gosuit/pg/v2\"\n\n")
+	fmt.Fprintf(&buf, "func init() {\n")
+
+	for _, name := range names {
+		fmt.Fprintf(&buf, "\tpg.RegisterMapper(%s)\n", mapperName(name))
+	}
+
+	fmt.Fprintf(&buf, "}\n")
+
+	for _, name := range names {
+		obj := pkg.Scope().Lookup(name)
+
+		st, ok := structOf(obj)
+		if !ok {
+			return nil, fmt.Errorf("struct %s not found", name)
+		}
+
+		if !isModelType(obj.Type()) {
+			return nil, fmt.Errorf("%s is mapped as a single value", name)
+		}
+
+		g := &fieldsGen{pkg: pkg, seen: map[types.Type]bool{obj.Type(): true}}
+
+		fields, err := g.getFields(st, "", "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		writeMapper(&buf, name, fields)
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// mapperName returns the name of the generated variable with the mapper of the type.
+func mapperName(name string) string {
+	return "pgMapper" + name
+}
+
+func writeMapper(buf *bytes.Buffer, name string, fields []field) {
+	fmt.Fprintf(buf, "\nvar %s = pg.Mapper[%s]{\n", mapperName(name), name)
+
+	fmt.Fprintf(buf, "\tKeys: []string{")
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString(strconv.Quote(f.key))
+	}
+	fmt.Fprintf(buf, "},\n")
+
+	fmt.Fprintf(buf, "\tArgs: func(m *%s, keys []int, args []any) []any {\n", name)
+	fmt.Fprintf(buf, "\t\tfor _, k := range keys {\n")
+	fmt.Fprintf(buf, "\t\t\tswitch k {\n")
+	for i, f := range fields {
+		fmt.Fprintf(buf, "\t\t\tcase %d:\n\t\t\t\targs = append(args, m.%s)\n", i, f.path)
+	}
+	fmt.Fprintf(buf, "\t\t\tdefault:\n\t\t\t\targs = append(args, nil)\n")
+	fmt.Fprintf(buf, "\t\t\t}\n")
+	fmt.Fprintf(buf, "\t\t}\n\n")
+	fmt.Fprintf(buf, "\t\treturn args\n")
+	fmt.Fprintf(buf, "\t},\n")
+
+	fmt.Fprintf(buf, "\tTargets: func(m *%s, keys []int, dests []any) {\n", name)
+	fmt.Fprintf(buf, "\t\tfor i, k := range keys {\n")
+	fmt.Fprintf(buf, "\t\t\tswitch k {\n")
+	for i, f := range fields {
+		fmt.Fprintf(buf, "\t\t\tcase %d:\n\t\t\t\tdests[i] = &m.%s\n", i, f.path)
+	}
+	fmt.Fprintf(buf, "\t\t\tdefault:\n\t\t\t\tdests[i] = nil\n")
+	fmt.Fprintf(buf, "\t\t\t}\n")
+	fmt.Fprintf(buf, "\t\t}\n")
+	fmt.Fprintf(buf, "\t},\n")
+
+	fmt.Fprintf(buf, "}\n")
+}
+
+// loadPackage parses and type-checks the package in dir without the generated file,
+// so that a stale output doesn't break the generation.
+func loadPackage(dir, output string) (*types.Package, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	parsed := []*ast.File{}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || filepath.Base(file) == output {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed = append(parsed, f)
+	}
+
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no go files in %s", dir)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+	}
+
+	return conf.Check(absDir, fset, parsed, nil)
+}
+
+func structOf(obj types.Object) (*types.Struct, bool) {
+	tn, ok := obj.(*types.TypeName)
+	if !ok || tn.IsAlias() {
+		return nil, false
+	}
+
+	named, ok := tn.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() != 0 {
+		return nil, false
+	}
+
+	st, ok := named.Underlying().(*types.Struct)
+
+	return st, ok
+}
+
+type fieldsGen struct {
+	pkg  *types.Package
+	seen map[types.Type]bool
+}
+
+// getFields returns the fields of the struct by the rules of getPaths of pg.
+func (g *fieldsGen) getFields(st *types.Struct, baseKey, basePath string) ([]field, error) {
+	var result []field
+
+	for i := range st.NumFields() {
+		f := st.Field(i)
+		isModel := isModelType(f.Type())
+
+		if !f.Exported() && !(f.Embedded() && isModel) {
+			continue
+		}
+
+		key, ok := reflect.StructTag(st.Tag(i)).Lookup(pgTag)
+		if ok && key == "-" {
+			continue
+		} else if !ok {
+			key = strings.ToLower(f.Name())
+		} else if !isValidKey(key) {
+			return nil, fmt.Errorf("field %s: invalid key %q", f.Name(), key)
+		}
+
+		if baseKey != "" {
+			key = baseKey + "." + key
+		}
+
+		if !f.Exported() && f.Pkg() != g.pkg {
+			return nil, fmt.Errorf("field %s: embedded struct of package %s is not accessible", f.Name(), f.Pkg().Path())
+		}
+
+		path := f.Name()
+		if basePath != "" {
+			path = basePath + "." + f.Name()
+		}
+
+		switch u := f.Type().Underlying().(type) {
+		case *types.Signature, *types.Chan:
+			return nil, fmt.Errorf("field %s: unsupported type %s", f.Name(), f.Type())
+		case *types.Basic:
+			if u.Kind() == types.UnsafePointer {
+				return nil, fmt.Errorf("field %s: unsupported type %s", f.Name(), f.Type())
+			}
+		}
+
+		if !isModel {
+			result = append(result, field{key: key, path: path})
+			continue
+		}
+
+		if g.seen[f.Type()] {
+			return nil, fmt.Errorf("recursive struct %s", f.Type())
+		}
+
+		g.seen[f.Type()] = true
+
+		fields, err := g.getFields(f.Type().Underlying().(*types.Struct), key, path)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(g.seen, f.Type())
+
+		result = append(result, fields...)
+	}
+
+	seen := make(map[string]bool, len(result))
+
+	for _, f := range result {
+		if seen[f.key] {
+			return nil, fmt.Errorf("duplicate key %q", f.key)
+		}
+
+		seen[f.key] = true
+	}
+
+	return result, nil
+}
+
+var scannerType = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "Scan", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewParam(token.NoPos, nil, "src", types.NewInterfaceType(nil, nil))),
+		types.NewTuple(types.NewParam(token.NoPos, nil, "", types.Universe.Lookup("error").Type())),
+		false,
+	)),
+}, nil).Complete()
+
+// isModelType reports whether the fields of the type are mapped, as isModelType of pg:
+// structs except time.Time and sql.Scanner implementations.
+func isModelType(t types.Type) bool {
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		return false
+	}
+
+	if named, ok := types.Unalias(t).(*types.Named); ok {
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return false
+		}
+	}
+
+	return !types.Implements(types.NewPointer(t), scannerType)
+}
+
+// isValidKey reports whether the key can be used in sql as "@key" or "#key", as isValidKey of pg.
+func isValidKey(key string) bool {
+	if key == "" || !isKeyStart(key[0]) {
+		return false
+	}
+
+	for i := range len(key) {
+		if !isKeyStart(key[i]) && !(key[i] >= '0' && key[i] <= '9') && key[i] != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isKeyStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func hasTags(st *types.Struct) bool {
+	for i := range st.NumFields() {
+		if _, ok := reflect.StructTag(st.Tag(i)).Lookup(pgTag); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateFixture(t *testing.T) {
+	dir := filepath.Join("internal", "fixture")
+
+	got, err := generate(dir, "pg_gen.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(filepath.Join(dir, "pg_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated code differs from %s, run go generate:\n%s", filepath.Join(dir, "pg_gen.go"), got)
+	}
+}
+
+func TestGenerateKeys(t *testing.T) {
+	got, err := generate(filepath.Join("internal", "fixture"), "pg_gen.go", []string{"Untagged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(got), `Keys: []string{"name"}`) {
+		t.Errorf("generated code has wrong keys:\n%s", got)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{
+			name: "func field",
+			src:  "type T struct {\n\tFn func() `pg:\"fn\"`\n}",
+			err:  "unsupported type",
+		},
+		{
+			name: "duplicate key",
+			src:  "type T struct {\n\tA int `pg:\"x\"`\n\tB int `pg:\"x\"`\n}",
+			err:  "duplicate key",
+		},
+		{
+			name: "invalid key",
+			src:  "type T struct {\n\tA int `pg:\"a b\"`\n}",
+			err:  "invalid key",
+		},
+		{
+			name: "scanner",
+			src:  "type T struct {\n\tA int `pg:\"a\"`\n}\n\nfunc (t *T) Scan(src any) error { return nil }",
+			err:  "single value",
+		},
+		{
+			name: "no tags",
+			src:  "type T struct {\n\tA int\n}",
+			err:  "no types",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			err := os.WriteFile(filepath.Join(dir, "models.go"), []byte("package models\n\n"+tt.src+"\n"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = generate(dir, "pg_gen.go", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -3,96 +3,321 @@ package pg
 import (
 	"context"
 	"errors"
+	"maps"
 	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// Command is immutable: methods which configure it return a copy, so it can be built once
+// and executed many times, also concurrently.
 type Command interface {
 	WithArgs(args ...*Argument) Command
 	WithArg(key string, value any) Command
+	From(src any) Command
 	Returning(dest any) Command
+	RequireAffected() Command
+	Prepared(name string) Command
 	Exec(ctx context.Context) error
+	ExecWithResult(ctx context.Context) (*ExecResult, error)
+}
+
+// ExecResult describes the result of the executed command.
+type ExecResult struct {
+	RowsAffected int64
+	Insert       bool
+	Update       bool
+	Delete       bool
+	Select       bool
+	Tag          string
+}
+
+func (r *ExecResult) add(tag pgconn.CommandTag) {
+	r.RowsAffected += tag.RowsAffected()
+	r.Insert = tag.Insert()
+	r.Update = tag.Update()
+	r.Delete = tag.Delete()
+	r.Select = tag.Select()
+	r.Tag = tag.String()
 }
 
 type command struct {
-	client        *client
-	sql           string
-	src           reflect.Value
-	dest          reflect.Value
-	withReturning bool
-	args          map[string]any
+	client          *client
+	sql             string
+	name            string
+	src             reflect.Value
+	dest            reflect.Value
+	withReturning   bool
+	requireAffected bool
+	args            map[string]any
+}
+
+func (c *command) clone() *command {
+	cp := *c
+	cp.args = maps.Clone(c.args)
+
+	return &cp
 }
 
 func (c *command) WithArgs(args ...*Argument) Command {
+	cp := c.clone()
+
 	for _, a := range args {
-		c.args[a.key] = a.value
+		cp.args[a.key] = a.value
 	}
 
-	return c
+	return cp
 }
 
 func (c *command) WithArg(key string, value any) Command {
-	c.args[key] = value
+	cp := c.clone()
+	cp.args[key] = value
+
+	return cp
+}
+
+// From returns the copy of the command with another src.
+func (c *command) From(src any) Command {
+	cp := c.clone()
+	cp.src = reflect.ValueOf(src)
 
-	return c
+	return cp
 }
 
 func (c *command) Returning(dest any) Command {
-	c.dest = reflect.ValueOf(dest)
-	c.withReturning = true
+	cp := c.clone()
+	cp.dest = reflect.ValueOf(dest)
+	cp.withReturning = true
 
-	return c
+	return cp
+}
+
+// RequireAffected makes Exec return ErrNoRowsAffected if the command has not affected any rows.
+func (c *command) RequireAffected() Command {
+	cp := c.clone()
+	cp.requireAffected = true
+
+	return cp
+}
+
+// Prepared returns the copy of the command which is prepared on the server under the name
+// before the first execution on each connection. For slice sources only the statements
+// with a full chunk of models are prepared, the rest of the models is sent unprepared,
+// so that varying slice lengths don't create a prepared statement per length.
+// Slices whose VALUES rows have no model keys are never prepared.
+func (c *command) Prepared(name string) Command {
+	cp := c.clone()
+	cp.name = name
+
+	return cp
 }
 
 func (c *command) Exec(ctx context.Context) error {
-	if c.src.Kind() != reflect.Pointer {
-		return errors.New("model must be pointer")
+	_, err := c.ExecWithResult(ctx)
+
+	return err
+}
+
+// ExecWithResult executes the command and returns its result.
+//
+// If src is a slice, the VALUES (...) tuple of the sql is repeated for every model.
+// Models are split into several statements so that each of them has no more than 65535 parameters.
+// The statements run in one transaction, and RowsAffected is summed up.
+func (c *command) ExecWithResult(ctx context.Context) (*ExecResult, error) {
+	statements, err := c.build()
+	if err != nil {
+		return nil, err
 	}
 
-	c.src = c.src.Elem()
+	if len(statements) <= 1 || InTx(ctx) {
+		return c.exec(ctx, statements)
+	}
+
+	var result *ExecResult
+
+	err = c.client.Transactional(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = c.exec(ctx, statements)
 
-	if c.src.Kind() != reflect.Struct {
-		return errors.New("model must be struct")
+		return err
+	})
+
+	return result, err
+}
+
+type statement struct {
+	sql  string
+	name string
+	args []any
+}
+
+func (c *command) build() ([]statement, error) {
+	src, err := c.getSrc()
+	if err != nil {
+		return nil, err
+	}
+
+	if src.Kind() == reflect.Slice {
+		return c.buildMany(src)
 	}
 
-	err := c.client.registerModel(c.src.Type())
+	err = c.client.registerModel(src.Type())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	sqlFunc, err := c.client.getSqlFunc(c.src.Type(), c.sql)
+	sqlFunc, err := c.client.getSqlFunc(src.Type(), c.sql)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	sql, sqlArgs, err := sqlFunc(c.src, c.args)
+	sql, sqlArgs, err := sqlFunc(src, c.args)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	qm := c.getQueryManager(ctx)
+	return []statement{{sql: sql, name: c.name, args: sqlArgs}}, nil
+}
 
-	if !c.withReturning {
-		_, err := qm.Exec(ctx, sql, sqlArgs...)
+func (c *command) buildMany(src reflect.Value) ([]statement, error) {
+	modelType := getDestModelType(src.Type())
+
+	fields, err := c.client.getModelFields(modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, err := c.client.getValuesSql(modelType, c.sql)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]reflect.Value, src.Len())
+
+	for i := range models {
+		models[i] = src.Index(i)
+
+		if models[i].Kind() == reflect.Pointer {
+			if models[i].IsNil() {
+				return nil, errors.New("model must not be nil")
+			}
+
+			models[i] = models[i].Elem()
+		}
+	}
+
+	chunkSize := vs.chunkSize(len(models))
+	statements := []statement{}
+
+	for start := 0; start < len(models); start += chunkSize {
+		end := min(start+chunkSize, len(models))
+
+		sql, sqlArgs, err := vs.build(models[start:end], fields, c.args, c.sql)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		st := statement{sql: sql, args: sqlArgs}
+		// Without model parameters the whole slice is one chunk, so its sql depends on the length.
+		if end-start == chunkSize && vs.rowParams != 0 {
+			st.name = c.name
 		}
-	} else {
-		rows, err := qm.Query(ctx, sql, sqlArgs...)
+
+		statements = append(statements, st)
+	}
+
+	return statements, nil
+}
+
+// getSrc returns the model or the slice of models of the command.
+// A slice can be passed as is or by pointer.
+func (c *command) getSrc() (reflect.Value, error) {
+	src := c.src
+
+	if src.Kind() == reflect.Pointer {
+		src = src.Elem()
+	} else if src.Kind() != reflect.Slice {
+		return reflect.Value{}, errors.New("model must be pointer")
+	}
+
+	if src.Kind() == reflect.Struct {
+		return src, nil
+	}
+
+	if src.Kind() == reflect.Slice && isModelType(getDestModelType(src.Type())) {
+		return src, nil
+	}
+
+	return reflect.Value{}, errors.New("model must be struct or slice of structs")
+}
+
+func (c *command) exec(ctx context.Context, statements []statement) (*ExecResult, error) {
+	if c.name == "" {
+		return c.run(ctx, c.client.getQueryManager(ctx), statements)
+	}
+
+	var result *ExecResult
+
+	err := c.client.withConn(ctx, func(conn *pgx.Conn) error {
+		var err error
+		result, err = c.run(ctx, conn, statements)
+
+		return err
+	})
+
+	return result, err
+}
+
+func (c *command) run(ctx context.Context, r runner, statements []statement) (*ExecResult, error) {
+	result := &ExecResult{}
+
+	for _, st := range statements {
+		tag, err := c.execStatement(ctx, r, st)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		return c.client.mapRowsToDest(rows, c.dest.Elem())
+		result.add(tag)
 	}
 
-	return nil
+	if c.requireAffected && result.RowsAffected == 0 {
+		return result, ErrNoRowsAffected
+	}
+
+	return result, nil
 }
 
-func (c *command) getQueryManager(ctx context.Context) queryManager {
-	tx, ok := getTxFromContext(ctx)
-	if ok {
-		return tx
+func (c *command) execStatement(ctx context.Context, r runner, st statement) (pgconn.CommandTag, error) {
+	sql, err := prepare(ctx, r, st.name, st.sql)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	if !c.withReturning {
+		tag, err := r.Exec(ctx, sql, st.args...)
+		if err != nil {
+			return tag, wrapError(err)
+		}
+
+		return tag, nil
+	}
+
+	rows, err := r.Query(ctx, sql, st.args...)
+	if err != nil {
+		return pgconn.CommandTag{}, wrapError(err)
+	}
+
+	err = c.client.mapRowsToDest(rows, c.dest.Elem(), c.sql)
+	tag := rows.CommandTag()
+
+	if err != nil {
+		if c.requireAffected && tag.RowsAffected() == 0 && errors.Is(err, ErrNotFound) {
+			return tag, ErrNoRowsAffected
+		}
+
+		return tag, err
 	}
 
-	return c.client.pool
+	return tag, nil
 }
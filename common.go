@@ -2,18 +2,47 @@ package pg
 
 import (
 	"context"
-	"reflect"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type queryManager interface {
+	runner
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// runner executes statements. It is implemented by queryManager and by results of a batch.
+type runner interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (commandTag pgconn.CommandTag, err error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
-type fnBase func(args []reflect.Value) (results []reflect.Value)
+type preparer interface {
+	Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error)
+}
+
+// prepare prepares the sql under the name if r supports it, and returns the sql to execute:
+// the name of the prepared statement or the sql itself.
+func prepare(ctx context.Context, r runner, name string, sql string) (string, error) {
+	if name == "" {
+		return sql, nil
+	}
+
+	p, ok := r.(preparer)
+	if !ok {
+		return sql, nil
+	}
+
+	_, err := p.Prepare(ctx, name, sql)
+	if err != nil {
+		return "", wrapError(err)
+	}
+
+	return name, nil
+}
 
 type Argument struct {
 	key   string
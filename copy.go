@@ -0,0 +1,445 @@
+package pg
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CopyFormat string
+
+const (
+	CopyText   CopyFormat = "text"
+	CopyCSV    CopyFormat = "csv"
+	CopyBinary CopyFormat = "binary"
+)
+
+type CopyOption func(opts *copyOptions)
+
+func WithFormat(format CopyFormat) CopyOption {
+	return func(opts *copyOptions) {
+		opts.format = format
+	}
+}
+
+// WithHeader adds the header line with column names. It is supported only for CSV format.
+func WithHeader() CopyOption {
+	return func(opts *copyOptions) {
+		opts.header = true
+	}
+}
+
+func WithDelimiter(delimiter string) CopyOption {
+	return func(opts *copyOptions) {
+		opts.delimiter = &delimiter
+	}
+}
+
+// WithNull sets the string which represents NULL values.
+func WithNull(null string) CopyOption {
+	return func(opts *copyOptions) {
+		opts.null = &null
+	}
+}
+
+// WithCopyArgs sets the values of "#" keys of the sql for CopyToWriter.
+func WithCopyArgs(args ...*Argument) CopyOption {
+	return func(opts *copyOptions) {
+		for _, a := range args {
+			opts.args[a.key] = a.value
+		}
+	}
+}
+
+type copyOptions struct {
+	format    CopyFormat
+	header    bool
+	delimiter *string
+	null      *string
+	args      map[string]any
+}
+
+func getCopyOptions(opts []CopyOption) *copyOptions {
+	result := &copyOptions{
+		format: CopyText,
+		args:   make(map[string]any),
+	}
+
+	for _, o := range opts {
+		o(result)
+	}
+
+	return result
+}
+
+func (o *copyOptions) sql() string {
+	options := []string{"FORMAT " + string(o.format)}
+
+	if o.header {
+		options = append(options, "HEADER true")
+	}
+
+	if o.delimiter != nil {
+		options = append(options, "DELIMITER "+quoteString(*o.delimiter))
+	}
+
+	if o.null != nil {
+		options = append(options, "NULL "+quoteString(*o.null))
+	}
+
+	return " WITH (" + strings.Join(options, ", ") + ")"
+}
+
+// CopyToWriter streams the result of the sql to w with COPY TO STDOUT and returns the number of copied rows.
+// The sql can contain "@" keys of src, which can be nil, and "#" keys set with WithCopyArgs.
+// Because COPY has no parameters, their values are written to the sql as literals.
+func (c *client) CopyToWriter(ctx context.Context, w io.Writer, sql string, src any, opts ...CopyOption) (int64, error) {
+	options := getCopyOptions(opts)
+
+	var n int64
+
+	err := c.withConn(ctx, func(conn *pgx.Conn) error {
+		query, err := c.inlineKeys(conn.TypeMap(), sql, src, options.args)
+		if err != nil {
+			return err
+		}
+
+		tag, err := conn.PgConn().CopyTo(ctx, w, fmt.Sprintf("COPY (%s) TO STDOUT%s", query, options.sql()))
+		if err != nil {
+			return wrapError(err)
+		}
+
+		n = tag.RowsAffected()
+
+		return nil
+	})
+
+	return n, err
+}
+
+// CopyFromReader loads the data from r to the table with COPY FROM STDIN and returns the number of copied rows.
+// If columns are empty, the data must contain all columns of the table.
+func (c *client) CopyFromReader(ctx context.Context, r io.Reader, table string, columns []string, opts ...CopyOption) (int64, error) {
+	options := getCopyOptions(opts)
+
+	query := "COPY " + pgx.Identifier(strings.Split(table, ".")).Sanitize()
+
+	if len(columns) != 0 {
+		quoted := make([]string, len(columns))
+
+		for i, column := range columns {
+			quoted[i] = quoteColumn(column)
+		}
+
+		query += " (" + strings.Join(quoted, ", ") + ")"
+	}
+
+	query += " FROM STDIN" + options.sql()
+
+	var n int64
+
+	err := c.withConn(ctx, func(conn *pgx.Conn) error {
+		tag, err := conn.PgConn().CopyFrom(ctx, r, query)
+		if err != nil {
+			return wrapError(err)
+		}
+
+		n = tag.RowsAffected()
+
+		return nil
+	})
+
+	return n, err
+}
+
+// inlineKeys replaces the keys of the sql with literals of their values.
+func (c *client) inlineKeys(typeMap *pgtype.Map, sql string, src any, args map[string]any) (string, error) {
+	tokens, err := lexSql(sql)
+	if err != nil {
+		return "", err
+	}
+
+	model := reflect.ValueOf(src)
+	if model.Kind() == reflect.Pointer {
+		model = model.Elem()
+	}
+
+	var fields *modelFields
+
+	if model.IsValid() {
+		fields, err = c.getModelFields(model.Type())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var sb strings.Builder
+
+	for _, t := range tokens {
+		if t.kind != keyToken {
+			sb.WriteString(t.text)
+			continue
+		}
+
+		var value any
+
+		if t.key.isModel {
+			var getter getter
+			var ok bool
+
+			if fields != nil {
+				getter, ok = fields.getters[t.key.key]
+			}
+
+			if !ok {
+				return "", &FieldNotFoundError{
+					Key:   t.key.key,
+					Model: reflect.TypeOf(src),
+					SQL:   sql,
+				}
+			}
+
+			value = getter(model).Interface()
+		} else {
+			var ok bool
+
+			value, ok = args[t.key.key]
+			if !ok {
+				return "", &ArgNotFoundError{
+					Key: t.key.key,
+					SQL: sql,
+				}
+			}
+		}
+
+		literal, err := quoteLiteral(typeMap, value)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(literal)
+	}
+
+	return sb.String(), nil
+}
+
+func isNestedKey(key string) bool {
+	return strings.Contains(key, ".")
+}
+
+// quoteLiteral encodes the value in text format and quotes it as a string literal,
+// which PostgreSQL casts to the type required by the context.
+func quoteLiteral(typeMap *pgtype.Map, value any) (string, error) {
+	value, err := literalValue(typeMap, value)
+	if err != nil {
+		return "", err
+	}
+
+	if value == nil {
+		return "NULL", nil
+	}
+
+	t, _ := typeMap.TypeForValue(value)
+
+	buf, err := typeMap.Encode(t.OID, pgtype.TextFormatCode, value, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if buf == nil {
+		return "NULL", nil
+	}
+
+	return quoteString(string(buf)), nil
+}
+
+// literalValue returns the value which type is known to the type map. Like for query parameters,
+// driver.Valuer values are replaced with their Value, and named types with their underlying types.
+func literalValue(typeMap *pgtype.Map, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if _, ok := typeMap.TypeForValue(value); ok {
+		return value, nil
+	}
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		return literalValue(typeMap, v)
+	}
+
+	rv := reflect.ValueOf(value)
+
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+
+		return literalValue(typeMap, rv.Elem().Interface())
+	}
+
+	underlying, ok := underlyingTypes[rv.Kind()]
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		underlying, ok = reflect.TypeFor[[]byte](), true
+	}
+
+	if !ok || rv.Type() == underlying {
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+
+	return literalValue(typeMap, rv.Convert(underlying).Interface())
+}
+
+var underlyingTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeFor[bool](),
+	reflect.String:  reflect.TypeFor[string](),
+	reflect.Int:     reflect.TypeFor[int](),
+	reflect.Int8:    reflect.TypeFor[int8](),
+	reflect.Int16:   reflect.TypeFor[int16](),
+	reflect.Int32:   reflect.TypeFor[int32](),
+	reflect.Int64:   reflect.TypeFor[int64](),
+	reflect.Uint:    reflect.TypeFor[uint](),
+	reflect.Uint8:   reflect.TypeFor[uint8](),
+	reflect.Uint16:  reflect.TypeFor[uint16](),
+	reflect.Uint32:  reflect.TypeFor[uint32](),
+	reflect.Uint64:  reflect.TypeFor[uint64](),
+	reflect.Float32: reflect.TypeFor[float32](),
+	reflect.Float64: reflect.TypeFor[float64](),
+}
+
+// quoteString quotes s as an escape string literal, so it is safe regardless of standard_conforming_strings.
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "'", "''")
+
+	return "E'" + s + "'"
+}
+
+// CopyFrom loads the models into the table with COPY FROM and returns the number of copied rows.
+// Src can be a slice of models, a slice of pointers to models or an iter.Seq of them.
+// Columns are the keys of model fields, by default all fields are copied.
+// Models with nested structs need explicit columns, because their dotted keys are not table columns.
+func (c *client) CopyFrom(ctx context.Context, table string, src any, columns ...string) (int64, error) {
+	value := reflect.ValueOf(src)
+
+	var next func() (reflect.Value, bool)
+	var modelType reflect.Type
+
+	switch {
+	case value.Kind() == reflect.Slice:
+		modelType = getDestModelType(value.Type())
+
+		i := 0
+		next = func() (reflect.Value, bool) {
+			if i >= value.Len() {
+				return reflect.Value{}, false
+			}
+
+			i++
+
+			return value.Index(i - 1), true
+		}
+	case value.Kind() == reflect.Func && value.Type().CanSeq():
+		modelType = value.Type().In(0).In(0)
+		if modelType.Kind() == reflect.Pointer {
+			modelType = modelType.Elem()
+		}
+
+		var stop func()
+		next, stop = iter.Pull(value.Seq())
+		defer stop()
+	default:
+		return 0, errors.New("src must be slice or iter.Seq of models")
+	}
+
+	if !isModelType(modelType) {
+		return 0, errors.New("src must contain structs")
+	}
+
+	fields, err := c.getModelFields(modelType)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(columns) == 0 {
+		columns = fields.keys
+
+		if i := slices.IndexFunc(columns, isNestedKey); i >= 0 {
+			return 0, fmt.Errorf("model %s has nested field %q, which is not a table column: pass the columns to copy explicitly", modelType, columns[i])
+		}
+	}
+
+	keys := fields.indexes(columns)
+
+	if i := slices.Index(keys, -1); i >= 0 {
+		return 0, &FieldNotFoundError{
+			Key:   columns[i],
+			Model: modelType,
+		}
+	}
+
+	rowSrc := &modelCopySource{
+		next:   next,
+		fields: fields,
+		keys:   keys,
+	}
+
+	qm := c.getQueryManager(ctx)
+
+	n, err := qm.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, rowSrc)
+	if err != nil {
+		return n, wrapError(err)
+	}
+
+	return n, nil
+}
+
+type modelCopySource struct {
+	next   func() (reflect.Value, bool)
+	fields *modelFields
+	keys   []int
+	values []any
+	err    error
+}
+
+func (s *modelCopySource) Next() bool {
+	model, ok := s.next()
+	if !ok {
+		return false
+	}
+
+	if model.Kind() == reflect.Pointer {
+		if model.IsNil() {
+			s.err = errors.New("model must not be nil")
+			return false
+		}
+
+		model = model.Elem()
+	}
+
+	s.values = s.fields.args(model, s.keys, make([]any, 0, len(s.keys)))
+
+	return true
+}
+
+func (s *modelCopySource) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *modelCopySource) Err() error {
+	return s.err
+}
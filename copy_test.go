@@ -0,0 +1,82 @@
+package pg
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type copyAddress struct {
+	City string `pg:"city"`
+}
+
+type copyUser struct {
+	Name    string      `pg:"name"`
+	Address copyAddress `pg:"address"`
+}
+
+func TestCopyFromNestedColumns(t *testing.T) {
+	c := &client{}
+
+	_, err := c.CopyFrom(context.Background(), "users", []copyUser{{Name: "a"}})
+	if err == nil || !strings.Contains(err.Error(), `nested field "address.city"`) {
+		t.Fatalf("error = %v, want nested field error", err)
+	}
+}
+
+type copyStatus string
+
+type copyLevel int16
+
+type copyValuer struct{ v string }
+
+func (v copyValuer) Value() (driver.Value, error) {
+	return v.v, nil
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	s := "x"
+	status := copyStatus("active")
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"nil", nil, "NULL"},
+		{"string", "it's", `E'it''s'`},
+		{"backslash", `a\b`, `E'a\\b'`},
+		{"int", 42, "E'42'"},
+		{"bool", true, "E't'"},
+		{"pointer", &s, "E'x'"},
+		{"nil pointer", (*string)(nil), "NULL"},
+		{"named string", copyStatus("active"), "E'active'"},
+		{"pointer to named string", &status, "E'active'"},
+		{"named int", copyLevel(3), "E'3'"},
+		{"valuer", copyValuer{v: "v"}, "E'v'"},
+		{"slice", []int{1, 2}, "E'{1,2}'"},
+	}
+
+	typeMap := pgtype.NewMap()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := quoteLiteral(typeMap, tt.value)
+			if err != nil {
+				t.Fatalf("quoteLiteral(%v) error: %v", tt.value, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("quoteLiteral(%v) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	_, err := quoteLiteral(typeMap, struct{ ch chan int }{})
+	if err == nil {
+		t.Error("want error for unsupported type")
+	}
+}
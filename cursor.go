@@ -0,0 +1,158 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"sync/atomic"
+)
+
+// Cursor is immutable: methods which configure it return a copy, so it can be built once
+// and executed many times. Copies share dest, so concurrent executions need their own dest set with Into.
+type Cursor interface {
+	WithArgs(args ...*Argument) Cursor
+	WithArg(key string, value any) Cursor
+	Into(dest any) Cursor
+	WithBatchSize(size int) Cursor
+	Each(ctx context.Context, fn func() error) error
+}
+
+const defaultCursorBatchSize = 1000
+
+var cursorCounter atomic.Uint64
+
+type cursor struct {
+	client    *client
+	sql       string
+	dest      reflect.Value
+	args      map[string]any
+	batchSize int
+}
+
+func (c *cursor) clone() *cursor {
+	cp := *c
+	cp.args = maps.Clone(c.args)
+
+	return &cp
+}
+
+func (c *cursor) WithArgs(args ...*Argument) Cursor {
+	cp := c.clone()
+
+	for _, a := range args {
+		cp.args[a.key] = a.value
+	}
+
+	return cp
+}
+
+func (c *cursor) WithArg(key string, value any) Cursor {
+	cp := c.clone()
+	cp.args[key] = value
+
+	return cp
+}
+
+// Into returns the copy of the cursor with another dest.
+func (c *cursor) Into(dest any) Cursor {
+	cp := c.clone()
+	cp.dest = reflect.ValueOf(dest)
+
+	return cp
+}
+
+func (c *cursor) WithBatchSize(size int) Cursor {
+	cp := c.clone()
+	cp.batchSize = size
+
+	return cp
+}
+
+// Each declares a server-side cursor for the sql and fetches it in batches into dest,
+// calling fn after each of them. Dest must be a pointer to slice and gets a new slice for every batch.
+// The cursor lives in the transaction from ctx, or in a new one if ctx has no transaction.
+func (c *cursor) Each(ctx context.Context, fn func() error) error {
+	if c.dest.Kind() != reflect.Pointer || c.dest.IsNil() || !isListType(c.dest.Elem().Type()) {
+		return errors.New("dest must be pointer to slice")
+	}
+
+	if c.batchSize <= 0 {
+		return errors.New("batch size must be positive")
+	}
+
+	dest := c.dest.Elem()
+
+	if InTx(ctx) {
+		return c.each(ctx, dest, fn)
+	}
+
+	return c.client.Transactional(ctx, func(ctx context.Context) error {
+		return c.each(ctx, dest, fn)
+	})
+}
+
+func (c *cursor) each(ctx context.Context, dest reflect.Value, fn func() error) (err error) {
+	tx, _ := TxFromContext(ctx)
+
+	modelType := getDestModelType(dest.Type())
+
+	err = c.client.registerModel(modelType)
+	if err != nil {
+		return err
+	}
+
+	sqlFunc, err := c.client.getSqlFunc(modelType, c.sql)
+	if err != nil {
+		return err
+	}
+
+	sql, sqlArgs, err := sqlFunc(dest, c.args)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("pg_cursor_%d", cursorCounter.Add(1))
+
+	_, err = tx.Exec(ctx, fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", name, sql), sqlArgs...)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	defer func() {
+		_, closeErr := tx.Exec(context.WithoutCancel(ctx), "CLOSE "+name)
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", c.batchSize, name)
+
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return wrapError(err)
+		}
+
+		dest.Set(reflect.MakeSlice(dest.Type(), 0, c.batchSize))
+
+		err = c.client.mapRowsToDest(rows, dest, c.sql)
+		if err != nil {
+			return err
+		}
+
+		if dest.Len() == 0 {
+			return nil
+		}
+
+		err = fn()
+		if err != nil {
+			return err
+		}
+
+		if dest.Len() < c.batchSize {
+			return nil
+		}
+	}
+}
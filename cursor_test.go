@@ -0,0 +1,60 @@
+package pg
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCursorImmutable(t *testing.T) {
+	base := (&client{}).Cursor("SELECT * FROM users WHERE name = #name", nil)
+
+	derived := base.WithArg("name", "a").WithArgs(Arg("org", 1)).WithBatchSize(10)
+
+	b := base.(*cursor)
+	d := derived.(*cursor)
+
+	if len(b.args) != 0 || b.batchSize != defaultCursorBatchSize {
+		t.Errorf("base cursor changed: args %v, batch size %d", b.args, b.batchSize)
+	}
+
+	if d.args["name"] != "a" || d.args["org"] != 1 || d.batchSize != 10 {
+		t.Errorf("derived cursor: args %v, batch size %d", d.args, d.batchSize)
+	}
+}
+
+func TestCursorInvalidDest(t *testing.T) {
+	tests := []struct {
+		name string
+		dest any
+	}{
+		{"nil", nil},
+		{"typed nil slice pointer", (*[]valuesUser)(nil)},
+		{"not pointer", []valuesUser{}},
+		{"pointer to struct", &valuesUser{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := (&client{}).Cursor("SELECT * FROM users", tt.dest)
+
+			if err := c.Each(context.Background(), func() error { return nil }); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestCursorInto(t *testing.T) {
+	var a, b []valuesUser
+
+	base := (&client{}).Cursor("SELECT * FROM users", &a)
+	derived := base.Into(&b).(*cursor)
+
+	if base.(*cursor).dest.Interface() != &a {
+		t.Error("base cursor dest changed")
+	}
+
+	if derived.dest.Interface() != &b {
+		t.Error("derived cursor dest is not set")
+	}
+}
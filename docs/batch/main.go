@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gosuit/pg/v2"
+)
+
+type User struct {
+	Name     string `pg:"name"`
+	Password string `pg:"password"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	cfg := &pg.Config{
+		Host:     "localhost",
+		Port:     5432,
+		DBName:   "postgres",
+		Username: "admin",
+		Password: "root",
+		SSLMode:  "disable",
+	}
+
+	// Init client
+	client, err := pg.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	var count int64
+	var admin User
+
+	u := User{
+		Name:     "user",
+		Password: "pass",
+	}
+
+	// pg.Client.Batch sends queries and commands in one round-trip.
+	// Destinations are filled when the batch is executed.
+	// Batch can be used inside pg.Client.Transactional with its context.
+	err = client.Batch().
+		AddQuery(client.Query("SELECT count(*) FROM users", &count)).
+		AddQuery(client.Query("SELECT * FROM users WHERE name = #name", &admin).WithArg("name", "admin")).
+		AddCommand(client.Command("INSERT INTO users VALUES (@name, @password)", &u)).
+		Exec(ctx)
+
+	// pg.BatchError contains errors of all items, nil for successful ones.
+	var batchErr *pg.BatchError
+	if errors.As(err, &batchErr) {
+		for i, err := range batchErr.Errors {
+			if err != nil {
+				log.Printf("item %d failed: %v", i, err)
+			}
+		}
+	}
+
+	fmt.Println(count, admin)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gosuit/pg/v2"
+)
+
+// pggen generates pg_gen.go with the mappers of the structs which have pg tags.
+// Run "go generate" after changing the models.
+//go:generate go run github.com/gosuit/pg/v2/cmd/pggen
+
+type Address struct {
+	City   string `pg:"city"`
+	Street string `pg:"street"`
+}
+
+type User struct {
+	Name     string  `pg:"name"`
+	Password string  `pg:"password"`
+	Address  Address `pg:"address"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	cfg := &pg.Config{
+		Host:     "localhost",
+		Port:     5432,
+		DBName:   "postgres",
+		Username: "admin",
+		Password: "root",
+		SSLMode:  "disable",
+	}
+
+	// Init client
+	client, err := pg.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	// Nothing changes in the usage: the client finds the generated mapper of User
+	// and binds and scans its fields with the generated code. Types without mappers still work via reflection.
+	// Structs from other packages are flattened as at runtime, since pggen type-checks the package.
+	users := []User{}
+
+	err = client.Query("SELECT name, password, city AS \"address.city\" FROM users", &users).Exec(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(users)
+}
@@ -0,0 +1,78 @@
+// Code generated by pggen. DO NOT EDIT.
+
+package main
+
+import "github.com/gosuit/pg/v2"
+
+func init() {
+	pg.RegisterMapper(pgMapperAddress)
+	pg.RegisterMapper(pgMapperUser)
+}
+
+var pgMapperAddress = pg.Mapper[Address]{
+	Keys: []string{"city", "street"},
+	Args: func(m *Address, keys []int, args []any) []any {
+		for _, k := range keys {
+			switch k {
+			case 0:
+				args = append(args, m.City)
+			case 1:
+				args = append(args, m.Street)
+			default:
+				args = append(args, nil)
+			}
+		}
+
+		return args
+	},
+	Targets: func(m *Address, keys []int, dests []any) {
+		for i, k := range keys {
+			switch k {
+			case 0:
+				dests[i] = &m.City
+			case 1:
+				dests[i] = &m.Street
+			default:
+				dests[i] = nil
+			}
+		}
+	},
+}
+
+var pgMapperUser = pg.Mapper[User]{
+	Keys: []string{"name", "password", "address.city", "address.street"},
+	Args: func(m *User, keys []int, args []any) []any {
+		for _, k := range keys {
+			switch k {
+			case 0:
+				args = append(args, m.Name)
+			case 1:
+				args = append(args, m.Password)
+			case 2:
+				args = append(args, m.Address.City)
+			case 3:
+				args = append(args, m.Address.Street)
+			default:
+				args = append(args, nil)
+			}
+		}
+
+		return args
+	},
+	Targets: func(m *User, keys []int, dests []any) {
+		for i, k := range keys {
+			switch k {
+			case 0:
+				dests[i] = &m.Name
+			case 1:
+				dests[i] = &m.Password
+			case 2:
+				dests[i] = &m.Address.City
+			case 3:
+				dests[i] = &m.Address.Street
+			default:
+				dests[i] = nil
+			}
+		}
+	},
+}
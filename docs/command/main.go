@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	"github.com/gosuit/pg/v2"
@@ -31,6 +32,7 @@ func main() {
 	}
 
 	// You can set values to query with "@" prefix.
+	// Keys can contain letters, digits and underscores; fields of nested structs are set with dots (@address.city).
 	// Also you can set values with args like in query
 	sql := "INSERT INTO users VALUES (@name, @password)"
 	u := User{
@@ -44,4 +46,32 @@ func main() {
 	}
 
 	// If sql-query has RETURNING command, you can set destination with pg.Command.Returning
+
+	// Slice of models can be inserted with one statement.
+	// The VALUES (...) tuple is repeated for every model, "#" args are set once.
+	// Large slices are split into several statements executed in one transaction.
+	users := []User{
+		{Name: "user1", Password: "pass1"},
+		{Name: "user2", Password: "pass2"},
+	}
+
+	var names []string
+
+	err = client.Command("INSERT INTO users VALUES (@name, @password) RETURNING name", users).Returning(&names).Exec(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	// pg.Command.ExecWithResult returns the number of affected rows and the kind of the command.
+	// With pg.Command.RequireAffected, pg.ErrNoRowsAffected is returned if no rows were affected.
+	sql = "UPDATE users SET password = @password WHERE name = @name"
+
+	result, err := client.Command(sql, &u).RequireAffected().ExecWithResult(ctx)
+	if errors.Is(err, pg.ErrNoRowsAffected) {
+		log.Println("user not found")
+	} else if err != nil {
+		panic(err)
+	}
+
+	log.Println(result.RowsAffected)
 }
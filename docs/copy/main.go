@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/gosuit/pg/v2"
+)
+
+type User struct {
+	Name     string `pg:"name"`
+	Password string `pg:"password"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	cfg := &pg.Config{
+		Host:     "localhost",
+		Port:     5432,
+		DBName:   "postgres",
+		Username: "admin",
+		Password: "root",
+		SSLMode:  "disable",
+	}
+
+	// Init client
+	client, err := pg.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	users := []User{
+		{Name: "user1", Password: "pass1"},
+		{Name: "user2", Password: "pass2"},
+	}
+
+	// pg.Client.CopyFrom loads models with COPY FROM, which is the fastest way to insert many rows.
+	// Src can be a slice of models or an iter.Seq of them (slices.Values, maps.Values, etc.).
+	// By default all model fields are copied, but you can choose the columns.
+	// Models with nested structs must always pass the columns.
+	n, err := client.CopyFrom(ctx, "users", users, "name", "password")
+	if err != nil {
+		panic(err)
+	}
+
+	log.Printf("%d rows copied", n)
+
+	// pg.Client.CopyToWriter streams the result of the query to io.Writer.
+	// The query accepts the same "@" and "#" keys as pg.Client.Query.
+	file, err := os.Create("users.csv")
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	_, err = client.CopyToWriter(ctx, file, "SELECT * FROM users WHERE name <> #name", nil,
+		pg.WithFormat(pg.CopyCSV),
+		pg.WithHeader(),
+		pg.WithDelimiter(";"),
+		pg.WithCopyArgs(pg.Arg("name", "admin")),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	// pg.Client.CopyFromReader loads data from io.Reader.
+	// Supported formats are pg.CopyText (default), pg.CopyCSV and pg.CopyBinary.
+	supplierFile, err := os.Open("supplier.csv")
+	if err != nil {
+		panic(err)
+	}
+	defer supplierFile.Close()
+
+	_, err = client.CopyFromReader(ctx, supplierFile, "users", []string{"name", "password"},
+		pg.WithFormat(pg.CopyCSV),
+		pg.WithHeader(),
+		pg.WithNull("NULL"),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gosuit/pg/v2"
+)
+
+type User struct {
+	Name     string `pg:"name"`
+	Password string `pg:"password"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	cfg := &pg.Config{
+		Host:     "localhost",
+		Port:     5432,
+		DBName:   "postgres",
+		Username: "admin",
+		Password: "root",
+		SSLMode:  "disable",
+	}
+
+	// Init client
+	client, err := pg.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	// pg.Client.Cursor declares a server-side cursor and fetches rows in batches,
+	// so tables bigger than memory can be walked through.
+	//
+	// The cursor is declared in the transaction from context, or in a new one.
+	// Dest gets a new slice for every batch.
+	var users []User
+
+	err = client.Cursor("SELECT * FROM users WHERE name <> #name", &users).
+		WithArg("name", "admin").
+		WithBatchSize(500).
+		Each(ctx, func() error {
+			fmt.Println(len(users))
+			return nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gosuit/pg/v2"
+)
+
+type User struct {
+	Name     string `pg:"name"`
+	Password string `pg:"password"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	cfg := &pg.Config{
+		Host:     "localhost",
+		Port:     5432,
+		DBName:   "postgres",
+		Username: "admin",
+		Password: "root",
+		SSLMode:  "disable",
+	}
+
+	// Init client
+	client, err := pg.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	// Query returns pg.ErrNotFound if there are no rows for struct destination
+	// and pg.ErrTooManyRows if there are more than one.
+	var u User
+
+	err = client.Query("SELECT * FROM users WHERE name = #name", &u).WithArg("name", "admin").Exec(ctx)
+	if errors.Is(err, pg.ErrNotFound) {
+		log.Println("user not found")
+	}
+
+	// Constraint violations are returned as typed errors:
+	//
+	//	- pg.UniqueViolationError
+	//	- pg.ForeignKeyViolationError
+	//	- pg.CheckViolationError
+	//	- pg.NotNullViolationError
+	//
+	// All of them contain the constraint, table and column names.
+	err = client.Command("INSERT INTO users VALUES (@name, @password)", &u).Exec(ctx)
+
+	var uniqueErr *pg.UniqueViolationError
+	if errors.As(err, &uniqueErr) {
+		log.Printf("constraint %s is violated", uniqueErr.Constraint)
+	}
+}
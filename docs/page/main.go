@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gosuit/pg/v2"
+)
+
+type User struct {
+	ID        int64     `pg:"id"`
+	Name      string    `pg:"name"`
+	CreatedAt time.Time `pg:"created_at"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	cfg := &pg.Config{
+		Host:     "localhost",
+		Port:     5432,
+		DBName:   "postgres",
+		Username: "admin",
+		Password: "root",
+		SSLMode:  "disable",
+	}
+
+	// Init client
+	client, err := pg.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	// pg.Query.Page selects a page of the query result.
+	//
+	// By default keyset pagination is used, so order columns must identify rows uniquely.
+	// Set Offset to use LIMIT/OFFSET instead. Order columns must be fields of the model.
+	opts := &pg.PageOptions{
+		Order: []pg.Order{pg.Desc("created_at"), pg.Asc("id")},
+		Limit: 20,
+		Total: true,
+	}
+
+	for {
+		var users []User
+
+		page, err := client.Query("SELECT * FROM users WHERE name <> #name", &users).
+			WithArg("name", "admin").
+			Page(ctx, opts)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Println(page.Total, users)
+
+		// Next is an opaque token, which can be returned to the client of your API.
+		if page.Next == "" {
+			break
+		}
+
+		opts.Token = page.Next
+	}
+}
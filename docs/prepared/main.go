@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/gosuit/pg/v2"
+)
+
+type User struct {
+	Name     string `pg:"name"`
+	Password string `pg:"password"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	cfg := &pg.Config{
+		Host:     "localhost",
+		Port:     5432,
+		DBName:   "postgres",
+		Username: "admin",
+		Password: "root",
+		SSLMode:  "disable",
+	}
+
+	// Init client
+	client, err := pg.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	// pg.Query and pg.Command are immutable: WithArg, Into, From, etc. return a copy.
+	// So they can be built once and executed many times, also concurrently if each execution gets its own dest with Into.
+	//
+	// With Prepared the statement is prepared on the server under the name
+	// before the first execution on each connection.
+	getUser := client.Query("SELECT * FROM users WHERE name = #name", nil).Prepared("get_user")
+
+	var wg sync.WaitGroup
+
+	for _, name := range []string{"admin", "user"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var u User
+
+			err := getUser.Into(&u).WithArg("name", name).Exec(ctx)
+			if err != nil {
+				log.Printf("failed to get user: %v", err)
+				return
+			}
+
+			fmt.Println(u)
+		}()
+	}
+
+	wg.Wait()
+
+	createUser := client.Command("INSERT INTO users VALUES (@name, @password)", nil).Prepared("create_user")
+
+	for _, u := range []User{{Name: "user1", Password: "pass1"}, {Name: "user2", Password: "pass2"}} {
+		err := createUser.From(&u).Exec(ctx)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
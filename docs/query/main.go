@@ -31,6 +31,14 @@ func main() {
 		log.Fatalf("failed to create client: %v", err)
 	}
 
+	// Models are parsed on the first query. Register them at startup to check their fields and tags early:
+	// invalid tags, duplicate keys and fields of func or chan types return *pg.ModelError.
+	// Unexported fields are skipped, as in encoding/json.
+	err = client.Register(User{})
+	if err != nil {
+		log.Fatalf("invalid model: %v", err)
+	}
+
 	// You can set values with model values with "@" prefix as in command
 	// Also you can set values with args with "#" prefix
 	sql := "SELECT * FROM users WHERE name = #name"
@@ -44,4 +52,32 @@ func main() {
 	}
 
 	fmt.Println(u)
+
+	// Dest can also be a slice of models, a single value or a slice of values.
+	// For values the result must have exactly one column.
+	var count int64
+
+	err = client.Query("SELECT count(*) FROM users", &count).Exec(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	var names []string
+
+	err = client.Query("SELECT name FROM users", &names).Exec(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(count, names)
+
+	// If columns are not known at compile time, you can use map[string]any or []map[string]any as dest.
+	var rows []map[string]any
+
+	err = client.Query("SELECT * FROM users WHERE name <> #name", &rows).WithArg("name", "admin").Exec(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(rows)
 }
@@ -43,6 +43,11 @@ func main() {
 	// pg.Client.Transactional will wrap the passed func in a transaction.
 	//
 	// For this you must use the context that the passed func receives in it`s arguments.
+	// Contexts derived from it (context.WithTimeout, context.WithValue, etc.) keep the transaction.
+	// You can check it with pg.InTx or get the pgx.Tx with pg.TxFromContext.
+	//
+	// Calling Transactional inside another transaction creates a SAVEPOINT.
+	// If the nested func fails, only the savepoint is rolled back and the outer transaction stays alive.
 	//
 	// If necessary, you can setup the transaction using optional arguments:
 	//
@@ -51,6 +56,9 @@ func main() {
 	//	- WithDeferrable
 	// 	- WithBeginQuery
 	// 	- WithCommitQuery
+	//	- WithRetry (re-runs the func on serialization failures and deadlocks)
+	//	- WithRetryMaxBackoff (limits the delay between attempts, 30 seconds by default)
+	//	- WithRetryHook (is called after each failed attempt, e.g. for logging)
 	//
 	err = client.Transactional(ctx, func(c context.Context) error {
 		sql := "INSERT INTO users VALUES (@name, @password)"
@@ -60,6 +68,14 @@ func main() {
 			return err
 		}
 
+		// Hooks run only after the data is committed (or rolled back).
+		err = pg.OnCommit(c, func(ctx context.Context) {
+			log.Printf("user %s created", u1.Name)
+		})
+		if err != nil {
+			return err
+		}
+
 		err = client.Command(sql, &u2).Exec(c)
 		if err != nil {
 			return err
@@ -70,4 +86,38 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed tx: %v", err)
 	}
+
+	// If the work can not fit in one func, you can manage the transaction manually with pg.Client.Begin.
+	// It accepts the same options and returns a context which must be passed to Query and Command.
+	tx, txCtx, err := client.Begin(ctx, pg.WithLevel(pg.Serializable))
+	if err != nil {
+		log.Fatalf("failed to begin tx: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sql := "INSERT INTO users VALUES (@name, @password)"
+
+	err = client.Command(sql, &u1).Exec(txCtx)
+	if err != nil {
+		log.Fatalf("failed to insert: %v", err)
+	}
+
+	// Savepoints allow to roll back a part of the transaction.
+	err = tx.Savepoint(ctx, "second_user")
+	if err != nil {
+		log.Fatalf("failed to create savepoint: %v", err)
+	}
+
+	err = client.Command(sql, &u2).Exec(txCtx)
+	if err != nil {
+		err = tx.RollbackTo(ctx, "second_user")
+		if err != nil {
+			log.Fatalf("failed to rollback to savepoint: %v", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Fatalf("failed to commit: %v", err)
+	}
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gosuit/pg/v2"
+)
+
+type User struct {
+	Name     string `pg:"name"`
+	Password string `pg:"password"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	cfg := &pg.Config{
+		Host:     "localhost",
+		Port:     5432,
+		DBName:   "postgres",
+		Username: "admin",
+		Password: "root",
+		SSLMode:  "disable",
+	}
+
+	// Init client
+	client, err := pg.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	// Typed functions use the same mapping and keys as pg.Client.Query,
+	// but the type of result is checked at compile time.
+	users, err := pg.All[User](ctx, client, "SELECT * FROM users")
+	if err != nil {
+		panic(err)
+	}
+
+	// pg.One returns pg.ErrNotFound if there are no rows.
+	admin, err := pg.One[User](ctx, client, "SELECT * FROM users WHERE name = #name", pg.Arg("name", "admin"))
+	if err != nil {
+		panic(err)
+	}
+
+	// pg.Optional returns nil if there are no rows.
+	root, err := pg.Optional[User](ctx, client, "SELECT * FROM users WHERE name = #name", pg.Arg("name", "root"))
+	if err != nil {
+		panic(err)
+	}
+
+	count, err := pg.One[int64](ctx, client, "SELECT count(*) FROM users")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(users, admin, root, count)
+
+	// pg.Iter maps rows one by one without loading the whole result in memory.
+	// Breaking out of the loop closes the rows.
+	for u, err := range pg.Iter[User](ctx, client, "SELECT * FROM users") {
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Println(u)
+	}
+
+	// The same is available for pg.Query with pg.Query.Each.
+	var u User
+
+	err = client.Query("SELECT * FROM users", &u).Each(ctx, func() error {
+		fmt.Println(u)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
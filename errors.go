@@ -0,0 +1,162 @@
+package pg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrNotFound    = errors.New("not found value")
+	ErrTooManyRows = errors.New("too many values")
+	ErrNoTx        = errors.New("no transaction in context")
+
+	ErrNoRowsAffected = errors.New("no rows affected")
+)
+
+// FieldNotFoundError is returned when a "@" key of the SQL does not match any field of the model.
+type FieldNotFoundError struct {
+	Key   string
+	Model reflect.Type
+	SQL   string
+}
+
+func (e *FieldNotFoundError) Error() string {
+	return fmt.Sprintf("model field %q not found in %s for sql %q", e.Key, e.Model, e.SQL)
+}
+
+// ModelError is returned when the field of the model can not be mapped.
+type ModelError struct {
+	Model  reflect.Type
+	Field  string
+	Reason string
+}
+
+func (e *ModelError) Error() string {
+	return fmt.Sprintf("invalid model %s: field %s: %s", e.Model, e.Field, e.Reason)
+}
+
+// ArgNotFoundError is returned when a "#" key of the SQL is not set with WithArg or WithArgs.
+type ArgNotFoundError struct {
+	Key string
+	SQL string
+}
+
+func (e *ArgNotFoundError) Error() string {
+	return fmt.Sprintf("arg %q not found for sql %q", e.Key, e.SQL)
+}
+
+// ScanError is returned when a value from the result can not be set to the model field.
+type ScanError struct {
+	Column string
+	Model  reflect.Type
+	Src    reflect.Type
+	Dest   reflect.Type
+	SQL    string
+
+	err error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("invalid value for column %q of %s: can`t convert %s to %s for sql %q: %v", e.Column, e.Model, e.Src, e.Dest, e.SQL, e.err)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.err
+}
+
+// BatchError is returned by Batch.Exec if any of its items failed.
+// Errors has an error for every item in the order they were added, nil for successful items.
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	messages := []string{}
+
+	for i, err := range e.Errors {
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("item %d: %v", i, err))
+		}
+	}
+
+	return "batch failed: " + strings.Join(messages, "; ")
+}
+
+func (e *BatchError) Unwrap() []error {
+	return slices.DeleteFunc(slices.Clone(e.Errors), func(err error) bool {
+		return err == nil
+	})
+}
+
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+	checkViolationCode      = "23514"
+	notNullViolationCode    = "23502"
+)
+
+// ConstraintError holds the details of a constraint violation reported by PostgreSQL.
+// It is embedded in the typed violation errors, which can be matched with errors.As.
+type ConstraintError struct {
+	Constraint string
+	Table      string
+	Column     string
+
+	err error
+}
+
+func (e *ConstraintError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.err
+}
+
+type UniqueViolationError struct {
+	ConstraintError
+}
+
+type ForeignKeyViolationError struct {
+	ConstraintError
+}
+
+type CheckViolationError struct {
+	ConstraintError
+}
+
+type NotNullViolationError struct {
+	ConstraintError
+}
+
+func wrapError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	base := ConstraintError{
+		Constraint: pgErr.ConstraintName,
+		Table:      pgErr.TableName,
+		Column:     pgErr.ColumnName,
+		err:        err,
+	}
+
+	switch pgErr.Code {
+	case uniqueViolationCode:
+		return &UniqueViolationError{base}
+	case foreignKeyViolationCode:
+		return &ForeignKeyViolationError{base}
+	case checkViolationCode:
+		return &CheckViolationError{base}
+	case notNullViolationCode:
+		return &NotNullViolationError{base}
+	default:
+		return err
+	}
+}
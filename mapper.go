@@ -0,0 +1,118 @@
+package pg
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Mapper binds and scans the fields of T with generated code instead of reflection.
+// It is usually generated by cmd/pggen. Keys are indexed by their position in Keys.
+type Mapper[T any] struct {
+	Keys []string
+	// Args appends the values of the fields with the indexes of keys to args.
+	Args func(model *T, keys []int, args []any) []any
+	// Targets sets dests to the pointers to the fields with the indexes of keys, or to nil for negative indexes.
+	Targets func(model *T, keys []int, dests []any)
+}
+
+var mappers sync.Map
+
+// RegisterMapper makes clients use the mapper for T instead of reflection.
+// It panics if the mapper doesn't match the fields of T, which means the generated code is out of date.
+func RegisterMapper[T any](m Mapper[T]) {
+	modelType := reflect.TypeFor[T]()
+
+	if !isModelType(modelType) {
+		panic(fmt.Sprintf("pg: can`t register mapper for non-model type %s", modelType))
+	}
+
+	paths, err := getPaths(modelType, "", []int{})
+	if err != nil {
+		panic(fmt.Sprintf("pg: %v", err))
+	}
+
+	if len(paths) != len(m.Keys) {
+		panic(fmt.Sprintf("pg: mapper for %s is out of date", modelType))
+	}
+
+	fields := newModelFields(slices.Clone(m.Keys))
+
+	all := make([]int, len(m.Keys))
+	for i := range all {
+		all[i] = i
+	}
+
+	model := new(T)
+	dests := make([]any, len(m.Keys))
+	m.Targets(model, all, dests)
+
+	for i, k := range m.Keys {
+		path, ok := paths[k]
+		if !ok {
+			panic(fmt.Sprintf("pg: mapper for %s is out of date: unknown field %q", modelType, k))
+		}
+
+		field := reflect.ValueOf(model).Elem().FieldByIndex(path)
+
+		target := reflect.ValueOf(dests[i])
+		if target.Kind() != reflect.Pointer || target.Pointer() != field.Addr().Pointer() || target.Elem().Type() != field.Type() {
+			panic(fmt.Sprintf("pg: mapper for %s is out of date: field %q doesn't match", modelType, k))
+		}
+
+		fields.types[k] = field.Type()
+		fields.getters[k] = getMapperGetter(m.Targets, i)
+	}
+
+	args := m.Args(model, all, nil)
+	if len(args) != len(m.Keys) {
+		panic(fmt.Sprintf("pg: mapper for %s is out of date: args don't match keys", modelType))
+	}
+
+	for i, k := range m.Keys {
+		if typ := fields.types[k]; typ.Kind() != reflect.Interface && reflect.TypeOf(args[i]) != typ {
+			panic(fmt.Sprintf("pg: mapper for %s is out of date: arg %q doesn't match", modelType, k))
+		}
+	}
+
+	fields.args = func(model reflect.Value, keys []int, args []any) []any {
+		return m.Args(modelPointer[T](model), keys, args)
+	}
+
+	fields.targets = func(model reflect.Value, keys []int, dests []any) {
+		m.Targets(modelPointer[T](model), keys, dests)
+	}
+
+	mappers.Store(modelType, fields)
+}
+
+func getMapper(modelType reflect.Type) (*modelFields, bool) {
+	fields, ok := mappers.Load(modelType)
+	if !ok {
+		return nil, false
+	}
+
+	return fields.(*modelFields), true
+}
+
+// modelPointer returns the pointer to the model. Models which are not addressable,
+// like values from iter.Seq, are copied.
+func modelPointer[T any](model reflect.Value) *T {
+	if model.CanAddr() {
+		return model.Addr().Interface().(*T)
+	}
+
+	cp := model.Interface().(T)
+
+	return &cp
+}
+
+func getMapperGetter[T any](targets func(model *T, keys []int, dests []any), key int) getter {
+	return func(model reflect.Value) reflect.Value {
+		dests := []any{nil}
+		targets(modelPointer[T](model), []int{key}, dests)
+
+		return reflect.ValueOf(dests[0]).Elem()
+	}
+}
@@ -0,0 +1,231 @@
+package pg
+
+import (
+	"iter"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type mappedAddress struct {
+	City string `pg:"city"`
+}
+
+type mappedUser struct {
+	ID      int64         `pg:"id"`
+	Name    string        `pg:"name"`
+	Address mappedAddress `pg:"address"`
+}
+
+// mappedUserMapper is written as cmd/pggen generates it.
+var mappedUserMapper = Mapper[mappedUser]{
+	Keys: []string{"id", "name", "address.city"},
+	Args: func(m *mappedUser, keys []int, args []any) []any {
+		for _, k := range keys {
+			switch k {
+			case 0:
+				args = append(args, m.ID)
+			case 1:
+				args = append(args, m.Name)
+			case 2:
+				args = append(args, m.Address.City)
+			default:
+				args = append(args, nil)
+			}
+		}
+
+		return args
+	},
+	Targets: func(m *mappedUser, keys []int, dests []any) {
+		for i, k := range keys {
+			switch k {
+			case 0:
+				dests[i] = &m.ID
+			case 1:
+				dests[i] = &m.Name
+			case 2:
+				dests[i] = &m.Address.City
+			default:
+				dests[i] = nil
+			}
+		}
+	},
+}
+
+func init() {
+	RegisterMapper(mappedUserMapper)
+}
+
+func TestMapperBind(t *testing.T) {
+	c := &client{}
+	u := mappedUser{ID: 1, Name: "a", Address: mappedAddress{City: "x"}}
+
+	fn, err := c.getSqlFunc(reflect.TypeFor[mappedUser](), "UPDATE users SET name = @name, city = @address.city WHERE id = @id AND org = #org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, args, err := fn(reflect.ValueOf(&u).Elem(), map[string]any{"org": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []any{"a", "x", int64(1), 2}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	_, _, err = fn(reflect.ValueOf(u), map[string]any{"org": 2})
+	if err != nil {
+		t.Errorf("not addressable model: %v", err)
+	}
+}
+
+func TestMapperValues(t *testing.T) {
+	users := []mappedUser{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+
+	cmd := &command{
+		client: &client{},
+		sql:    "INSERT INTO users (id, name) VALUES (@id, @name)",
+		src:    reflect.ValueOf(users),
+	}
+
+	statements, err := cmd.build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []any{int64(1), "a", int64(2), "b"}; !reflect.DeepEqual(statements[0].args, want) {
+		t.Errorf("args = %v, want %v", statements[0].args, want)
+	}
+}
+
+func TestMapperScan(t *testing.T) {
+	c := &client{}
+
+	rows := newFakeRows(t, 1,
+		fakeColumn{"name", pgtype.TextOID, "a"},
+		fakeColumn{"unknown", pgtype.Int4OID, int32(5)},
+		fakeColumn{"address.city", pgtype.TextOID, nil},
+		fakeColumn{"id", pgtype.Int8OID, int64(7)},
+	)
+
+	u := mappedUser{Address: mappedAddress{City: "old"}}
+
+	err := c.newRowMapper(reflect.TypeFor[mappedUser](), "sql")(rows, reflect.ValueOf(&u).Elem())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (mappedUser{ID: 7, Name: "a"}); u != want {
+		t.Errorf("model = %+v, want %+v", u, want)
+	}
+}
+
+func TestMapperCopySource(t *testing.T) {
+	fields, err := parseModel(reflect.TypeFor[mappedUser]())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seq := reflect.ValueOf(iter.Seq[mappedUser](slices.Values([]mappedUser{{ID: 1, Name: "a"}})))
+	next, stop := iter.Pull(seq.Seq())
+	defer stop()
+
+	src := &modelCopySource{next: next, fields: fields, keys: fields.indexes([]string{"name", "id"})}
+
+	if !src.Next() {
+		t.Fatal("no rows")
+	}
+
+	values, _ := src.Values()
+	if want := []any{"a", int64(1)}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+}
+
+type staleUser struct {
+	ID   int64  `pg:"id"`
+	Name string `pg:"name"`
+}
+
+func TestRegisterMapperStale(t *testing.T) {
+	targets := func(fields ...func(m *staleUser) any) func(m *staleUser, keys []int, dests []any) {
+		return func(m *staleUser, keys []int, dests []any) {
+			for i, k := range keys {
+				dests[i] = fields[k](m)
+			}
+		}
+	}
+
+	args := func(m *staleUser, keys []int, args []any) []any {
+		for _, k := range keys {
+			args = append(args, []any{m.ID, m.Name}[k])
+		}
+
+		return args
+	}
+
+	id := func(m *staleUser) any { return &m.ID }
+	name := func(m *staleUser) any { return &m.Name }
+
+	tests := []struct {
+		name   string
+		mapper Mapper[staleUser]
+	}{
+		{"missing key", Mapper[staleUser]{Keys: []string{"id"}, Args: args, Targets: targets(id)}},
+		{"unknown key", Mapper[staleUser]{Keys: []string{"id", "title"}, Args: args, Targets: targets(id, name)}},
+		{"wrong field", Mapper[staleUser]{Keys: []string{"id", "name"}, Args: args, Targets: targets(id, id)}},
+		{"wrong args", Mapper[staleUser]{
+			Keys:    []string{"id", "name"},
+			Args:    func(m *staleUser, keys []int, args []any) []any { return append(args, m.Name, m.ID) },
+			Targets: targets(id, name),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("want panic")
+				}
+			}()
+
+			RegisterMapper(tt.mapper)
+		})
+	}
+
+	if _, ok := getMapper(reflect.TypeFor[staleUser]()); ok {
+		t.Error("stale mapper is registered")
+	}
+}
+
+type unmappedUser mappedUser
+
+func BenchmarkMapperScanRow(b *testing.B) {
+	columns := []fakeColumn{
+		{"id", pgtype.Int8OID, int64(1)},
+		{"name", pgtype.TextOID, "admin"},
+		{"address.city", pgtype.TextOID, "city"},
+	}
+
+	for name, modelType := range map[string]reflect.Type{
+		"reflection": reflect.TypeFor[unmappedUser](),
+		"mapper":     reflect.TypeFor[mappedUser](),
+	} {
+		b.Run(name, func(b *testing.B) {
+			rows := newFakeRows(b, -1, columns...)
+			model := reflect.New(modelType).Elem()
+			mapRow := (&client{}).newRowMapper(modelType, "sql")
+
+			b.ReportAllocs()
+
+			for b.Loop() {
+				if err := mapRow(rows, model); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
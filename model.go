@@ -1,11 +1,13 @@
 package pg
 
 import (
-	"errors"
+	"database/sql"
+	"fmt"
 	"maps"
 	"reflect"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,142 +17,237 @@ const (
 
 var specificTypes = []reflect.Type{reflect.TypeFor[time.Time]()}
 
+var scannerType = reflect.TypeFor[sql.Scanner]()
+
+// isModelType reports whether values of the type are mapped field by field.
+// Other types, like time.Time or sql.Scanner implementations, are scanned as a single value.
+func isModelType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		!slices.Contains(specificTypes, t) &&
+		!reflect.PointerTo(t).Implements(scannerType)
+}
+
+// isListType reports whether the type gets a value per row. []byte is a single value.
+func isListType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// getDestModelType returns the type which is mapped from a single row of the dest.
+func getDestModelType(destType reflect.Type) reflect.Type {
+	if !isListType(destType) {
+		return destType
+	}
+
+	elemType := destType.Elem()
+
+	if elemType.Kind() == reflect.Pointer && isModelType(elemType.Elem()) {
+		return elemType.Elem()
+	}
+
+	return elemType
+}
+
 type parsedModel struct {
-	fields  *modelFields
-	queries map[string]sqlFunc
+	fields *modelFields
+	// queries and multiQueries cache the parsed sql of the model, they hold sqlFunc and *valuesSql.
+	queries      sync.Map
+	multiQueries sync.Map
 }
 
 type modelFields struct {
+	// keys are ordered as the fields of the model.
+	keys    []string
+	index   map[string]int
+	types   map[string]reflect.Type
 	getters map[string]getter
-	setters map[string]setter
+	// args appends the values of the fields with the indexes of keys to args.
+	args func(model reflect.Value, keys []int, args []any) []any
+	// targets sets dests to the pointers to the fields with the indexes of keys, or to nil for negative indexes.
+	targets func(model reflect.Value, keys []int, dests []any)
+}
+
+func newModelFields(keys []string) *modelFields {
+	fields := &modelFields{
+		keys:    keys,
+		index:   make(map[string]int, len(keys)),
+		types:   make(map[string]reflect.Type, len(keys)),
+		getters: make(map[string]getter, len(keys)),
+	}
+
+	for i, k := range keys {
+		fields.index[k] = i
+	}
+
+	return fields
 }
 
+// indexes returns the indexes of the keys in fields, or -1 for keys without fields.
+func (f *modelFields) indexes(keys []string) []int {
+	result := make([]int, len(keys))
+
+	for i, k := range keys {
+		idx, ok := f.index[k]
+		if !ok {
+			idx = -1
+		}
+
+		result[i] = idx
+	}
+
+	return result
+}
+
+// parseModel returns the fields of the model. Models with registered mappers don't use reflection for the fields.
 func parseModel(modelType reflect.Type) (*modelFields, error) {
-	paths := getPaths(modelType, "", []int{})
+	if fields, ok := getMapper(modelType); ok {
+		return fields, nil
+	}
+
+	if !isModelType(modelType) {
+		return newModelFields(nil), nil
+	}
+
+	paths, err := getPaths(modelType, "", []int{})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := slices.Collect(maps.Keys(paths))
+
+	slices.SortFunc(keys, func(a, b string) int {
+		return slices.Compare(paths[a], paths[b])
+	})
+
+	meta := newModelFields(keys)
+	getters := make([]getter, len(keys))
+	targets := make([]target, len(keys))
+
+	for i, k := range keys {
+		meta.types[k] = modelType.FieldByIndex(paths[k]).Type
+		meta.getters[k] = getGetter(paths[k])
+		getters[i] = meta.getters[k]
+		targets[i] = getTarget(paths[k])
+	}
 
-	meta := &modelFields{
-		getters: make(map[string]getter),
-		setters: make(map[string]setter),
+	meta.args = func(model reflect.Value, keys []int, args []any) []any {
+		for _, k := range keys {
+			args = append(args, getters[k](model).Interface())
+		}
+
+		return args
 	}
 
-	for k, v := range paths {
-		meta.getters[k] = getGetter(v)
-		meta.setters[k] = getSetter(v)
+	meta.targets = func(model reflect.Value, keys []int, dests []any) {
+		for i, k := range keys {
+			if k < 0 {
+				dests[i] = nil
+			} else {
+				dests[i] = targets[k](model)
+			}
+		}
 	}
 
 	return meta, nil
 }
 
-func getPaths(modelType reflect.Type, baseKey string, basePath []int) map[string][]int {
+var invalidKinds = []reflect.Kind{reflect.Func, reflect.Chan, reflect.UnsafePointer}
+
+// getPaths returns the index paths of the model fields by keys.
+// It checks that every field can be mapped, so invalid models fail before the first query.
+func getPaths(modelType reflect.Type, baseKey string, basePath []int) (map[string][]int, error) {
 	result := make(map[string][]int)
 
 	for i := range modelType.NumField() {
 		fieldStructType := modelType.Field(i)
+		isModel := isModelType(fieldStructType.Type)
+
+		// Unexported fields are skipped as in encoding/json, except embedded structs with exported fields.
+		if !fieldStructType.IsExported() && !(fieldStructType.Anonymous && isModel) {
+			continue
+		}
 
 		key, ok := fieldStructType.Tag.Lookup(pgTag)
 		if ok && key == "-" {
 			continue
 		} else if !ok {
 			key = strings.ToLower(fieldStructType.Name)
+		} else if !isValidKey(key) {
+			return nil, &ModelError{Model: modelType, Field: fieldStructType.Name, Reason: fmt.Sprintf("invalid key %q", key)}
 		}
 
 		if baseKey != "" {
 			key = baseKey + "." + key
 		}
 
-		path := make([]int, 0)
+		// The path is copied, so that the paths of sibling fields don't share the backing array.
+		path := slices.Concat(basePath, []int{i})
 
-		if len(basePath) != 0 {
-			path = append(basePath, i)
-		} else {
-			path = append(path, i)
+		if slices.Contains(invalidKinds, fieldStructType.Type.Kind()) {
+			return nil, &ModelError{Model: modelType, Field: fieldStructType.Name, Reason: fmt.Sprintf("unsupported type %s", fieldStructType.Type)}
 		}
 
-		if fieldStructType.Type.Kind() != reflect.Struct || slices.Contains(specificTypes, fieldStructType.Type) {
-			result[key] = path
-		} else {
-			toAdd := getPaths(modelType.Field(i).Type, key, path)
+		toAdd := map[string][]int{key: path}
 
-			maps.Copy(result, toAdd)
-		}
-	}
-
-	return result
-}
+		if isModel {
+			var err error
 
-type setter = func(model reflect.Value, value reflect.Value) error
-type getter = func(reflect.Value) reflect.Value
+			toAdd, err = getPaths(fieldStructType.Type, key, path)
+			if err != nil {
+				return nil, err
+			}
+		}
 
-func getSetter(indexPath []int) setter {
-	base := getSetterBase(indexPath)
+		for k, v := range toAdd {
+			if _, ok := result[k]; ok {
+				return nil, &ModelError{Model: modelType, Field: fieldStructType.Name, Reason: fmt.Sprintf("duplicate key %q", k)}
+			}
 
-	setterIn := []reflect.Type{reflect.TypeFor[reflect.Value](), reflect.TypeFor[reflect.Value]()}
-	setterOut := []reflect.Type{reflect.TypeFor[error]()}
-	setterType := reflect.FuncOf(setterIn, setterOut, false)
+			result[k] = v
+		}
+	}
 
-	return reflect.MakeFunc(setterType, base).Interface().(setter)
+	return result, nil
 }
 
-func getGetter(indexPath []int) getter {
-	base := getGetterBase(indexPath)
+// isValidKey reports whether the key can be used in sql as "@key" or "#key".
+func isValidKey(key string) bool {
+	if key == "" || !isKeyStart(key[0]) {
+		return false
+	}
 
-	getterIn := []reflect.Type{reflect.TypeFor[reflect.Value]()}
-	getterOut := []reflect.Type{reflect.TypeFor[reflect.Value]()}
-	getterType := reflect.FuncOf(getterIn, getterOut, false)
+	for i := range len(key) {
+		if !isKeyChar(key[i]) {
+			return false
+		}
+	}
 
-	return reflect.MakeFunc(getterType, base).Interface().(getter)
+	return true
 }
 
-func getSetterBase(indexPath []int) fnBase {
-	return func(args []reflect.Value) (results []reflect.Value) {
-		model := args[0].Interface().(reflect.Value)
-		value := args[1].Interface().(reflect.Value)
+// getter returns the field of the model.
+type getter = func(model reflect.Value) reflect.Value
 
-		var field reflect.Value
-		var err error
-
-		for i := range indexPath {
-			if i == 0 {
-				field = model.Field(indexPath[i])
-			} else {
-				field = field.Field(indexPath[i])
-			}
-		}
+// target returns the pointer to the field of the model to scan the value into.
+type target = func(model reflect.Value) any
 
-		if !value.IsValid() {
-			field.SetZero()
-		} else if value.CanConvert(field.Type()) {
-			field.Set(value.Convert(field.Type()))
-		} else {
-			err = errors.New("invalid value")
-		}
+func getGetter(indexPath []int) getter {
+	if len(indexPath) == 1 {
+		index := indexPath[0]
 
-		if err != nil {
-			results = append(results, reflect.ValueOf(err))
-		} else {
-			results = append(results, reflect.Zero(reflect.TypeOf((*error)(nil)).Elem()))
+		return func(model reflect.Value) reflect.Value {
+			return model.Field(index)
 		}
+	}
 
-		return results
+	return func(model reflect.Value) reflect.Value {
+		return model.FieldByIndex(indexPath)
 	}
 }
 
-func getGetterBase(indexPath []int) fnBase {
-	return func(args []reflect.Value) (results []reflect.Value) {
-		model := args[0].Interface().(reflect.Value)
-
-		var result reflect.Value
-
-		for i := range indexPath {
-			if i == 0 {
-				result = model.Field(indexPath[i])
-			} else {
-				result = result.Field(indexPath[i])
-			}
-		}
-
-		results = append(results, reflect.ValueOf(result))
+func getTarget(indexPath []int) target {
+	get := getGetter(indexPath)
 
-		return results
+	return func(model reflect.Value) any {
+		return get(model).Addr().Interface()
 	}
 }
@@ -0,0 +1,159 @@
+package pg
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type deepD struct {
+	X int
+	Y int
+}
+
+type deepC struct{ D deepD }
+
+type deepB struct{ C deepC }
+
+type deepA struct{ B deepB }
+
+type embeddedBase struct {
+	ID int `pg:"id"`
+}
+
+type withUnexported struct {
+	embeddedBase
+	Name    string    `pg:"name"`
+	Created time.Time `pg:"created"`
+	cache   map[string]any
+	mu      sync.Mutex
+}
+
+func TestGetPathsDeepNesting(t *testing.T) {
+	paths, err := getPaths(reflect.TypeFor[deepA](), "", []int{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]int{
+		"b.c.d.x": {0, 0, 0, 0},
+		"b.c.d.y": {0, 0, 0, 1},
+	}
+
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+
+	fields, err := parseModel(reflect.TypeFor[deepA]())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var model deepA
+	v := reflect.ValueOf(&model).Elem()
+
+	dests := make([]any, 2)
+	fields.targets(v, fields.indexes([]string{"b.c.d.x", "b.c.d.y"}), dests)
+
+	*(dests[0].(*int)) = 1
+	*(dests[1].(*int)) = 2
+
+	if model.B.C.D.X != 1 || model.B.C.D.Y != 2 {
+		t.Errorf("model = %+v, want X = 1 and Y = 2", model)
+	}
+}
+
+func TestParseModelSkipsUnexported(t *testing.T) {
+	fields, err := parseModel(reflect.TypeFor[withUnexported]())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"embeddedbase.id", "name", "created"}; !slices.Equal(fields.keys, want) {
+		t.Errorf("keys = %v, want %v", fields.keys, want)
+	}
+}
+
+func TestParseModelErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		model reflect.Type
+	}{
+		{"duplicate key", reflect.TypeFor[struct {
+			A int `pg:"x"`
+			B int `pg:"x"`
+		}]()},
+		{"duplicate nested key", reflect.TypeFor[struct {
+			A deepD `pg:"d"`
+			B int   `pg:"d.x"`
+		}]()},
+		{"invalid key", reflect.TypeFor[struct {
+			A int `pg:"a b"`
+		}]()},
+		{"empty key", reflect.TypeFor[struct {
+			A int `pg:""`
+		}]()},
+		{"func", reflect.TypeFor[struct{ Fn func() }]()},
+		{"chan", reflect.TypeFor[struct{ Ch chan int }]()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseModel(tt.model)
+
+			var modelErr *ModelError
+			if !errors.As(err, &modelErr) {
+				t.Fatalf("error = %v, want *ModelError", err)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	c := &client{}
+
+	if err := c.Register(withUnexported{}, &deepA{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.models.Load(reflect.TypeFor[deepA]()); !ok {
+		t.Error("model is not registered")
+	}
+
+	if err := c.Register(1); err == nil {
+		t.Error("want error for non-struct model")
+	}
+
+	if err := c.Register(struct{ Fn func() }{}); err == nil {
+		t.Error("want error for invalid model")
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	c := &client{}
+
+	var wg sync.WaitGroup
+
+	for i := range 16 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sql := "SELECT * FROM t WHERE x = @b.c.d.x"
+			if i%2 == 0 {
+				sql = "SELECT * FROM t WHERE y = @b.c.d.y"
+			}
+
+			_, err := c.getSqlFunc(reflect.TypeFor[deepA](), sql)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
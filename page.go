@@ -0,0 +1,265 @@
+package pg
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+func Asc(column string) Order {
+	return Order{Column: column}
+}
+
+func Desc(column string) Order {
+	return Order{Column: column, Desc: true}
+}
+
+// PageOptions describes the requested page.
+//
+// By default keyset pagination is used: the next page starts after the Order values of the last row,
+// so Order must identify rows uniquely (add a primary key as the last column) and must not contain NULL values.
+// With Offset, pages are selected with LIMIT and OFFSET.
+type PageOptions struct {
+	Order  []Order
+	Limit  int
+	Token  string
+	Offset bool
+	Total  bool
+}
+
+// PageResult contains the token for the next page, which is empty for the last page,
+// and the total number of rows if PageOptions.Total is set.
+type PageResult struct {
+	Next  string
+	Total int64
+}
+
+type pageToken struct {
+	Offset int               `json:"o,omitempty"`
+	Keys   []json.RawMessage `json:"k,omitempty"`
+}
+
+// Page selects the page of the query result into dest, which must be a pointer to slice of models.
+// The order columns must be fields of the model.
+func (q *query) Page(ctx context.Context, opts *PageOptions) (*PageResult, error) {
+	dest, err := q.getDest()
+	if err != nil {
+		return nil, err
+	}
+
+	modelType := getDestModelType(dest.Type())
+	if !isListType(dest.Type()) || !isModelType(modelType) {
+		return nil, errors.New("dest must be pointer to slice of structs")
+	}
+
+	if opts == nil {
+		return nil, errors.New("page options must not be nil")
+	}
+
+	if opts.Limit <= 0 {
+		return nil, errors.New("page limit must be positive")
+	}
+
+	if !opts.Offset && len(opts.Order) == 0 {
+		return nil, errors.New("keyset pagination requires order")
+	}
+
+	fields, err := q.client.getModelFields(modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range opts.Order {
+		if _, ok := fields.getters[o.Column]; !ok {
+			return nil, &FieldNotFoundError{
+				Key:   o.Column,
+				Model: modelType,
+				SQL:   q.sql,
+			}
+		}
+	}
+
+	token, err := decodePageToken(opts.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	baseSql, baseArgs, err := q.build(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, sqlArgs, err := getPageSql(baseSql, baseArgs, opts, token, fields, modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	qm := q.client.getQueryManager(ctx)
+
+	rows, err := qm.Query(ctx, sql, sqlArgs...)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	dest.Set(reflect.MakeSlice(dest.Type(), 0, opts.Limit+1))
+
+	err = q.client.mapRowsToDest(rows, dest, q.sql)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PageResult{}
+
+	if dest.Len() > opts.Limit {
+		dest.SetLen(opts.Limit)
+
+		result.Next, err = getNextPageToken(dest, opts, token, fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Total {
+		countSql := fmt.Sprintf("SELECT count(*) FROM (%s) AS pg_page", baseSql)
+
+		err = qm.QueryRow(ctx, countSql, baseArgs...).Scan(&result.Total)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+	}
+
+	return result, nil
+}
+
+func getPageSql(baseSql string, baseArgs []any, opts *PageOptions, token *pageToken, fields *modelFields, modelType reflect.Type) (string, []any, error) {
+	var sb strings.Builder
+	sqlArgs := baseArgs
+
+	sb.WriteString(fmt.Sprintf("SELECT * FROM (%s) AS pg_page", baseSql))
+
+	if !opts.Offset && len(token.Keys) != 0 {
+		if len(token.Keys) != len(opts.Order) {
+			return "", nil, errors.New("page token does not match order")
+		}
+
+		zero := reflect.New(modelType).Elem()
+		placeholders := make([]string, len(opts.Order))
+
+		for i, o := range opts.Order {
+			value := reflect.New(fields.getters[o.Column](zero).Type())
+
+			err := json.Unmarshal(token.Keys[i], value.Interface())
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid page token: %w", err)
+			}
+
+			sqlArgs = append(sqlArgs, value.Elem().Interface())
+			placeholders[i] = fmt.Sprintf("$%d", len(sqlArgs))
+		}
+
+		conditions := make([]string, len(opts.Order))
+
+		for i, o := range opts.Order {
+			parts := make([]string, 0, i+1)
+
+			for j := range i {
+				parts = append(parts, fmt.Sprintf("%s = %s", quoteColumn(opts.Order[j].Column), placeholders[j]))
+			}
+
+			op := ">"
+			if o.Desc {
+				op = "<"
+			}
+
+			parts = append(parts, fmt.Sprintf("%s %s %s", quoteColumn(o.Column), op, placeholders[i]))
+			conditions[i] = "(" + strings.Join(parts, " AND ") + ")"
+		}
+
+		sb.WriteString(" WHERE " + strings.Join(conditions, " OR "))
+	}
+
+	if len(opts.Order) != 0 {
+		columns := make([]string, len(opts.Order))
+
+		for i, o := range opts.Order {
+			columns[i] = quoteColumn(o.Column)
+			if o.Desc {
+				columns[i] += " DESC"
+			}
+		}
+
+		sb.WriteString(" ORDER BY " + strings.Join(columns, ", "))
+	}
+
+	sb.WriteString(fmt.Sprintf(" LIMIT %d", opts.Limit+1))
+
+	if opts.Offset && token.Offset > 0 {
+		sb.WriteString(fmt.Sprintf(" OFFSET %d", token.Offset))
+	}
+
+	return sb.String(), sqlArgs, nil
+}
+
+func getNextPageToken(dest reflect.Value, opts *PageOptions, token *pageToken, fields *modelFields) (string, error) {
+	next := &pageToken{}
+
+	if opts.Offset {
+		next.Offset = token.Offset + opts.Limit
+	} else {
+		last := dest.Index(dest.Len() - 1)
+		if last.Kind() == reflect.Pointer {
+			last = last.Elem()
+		}
+
+		for _, o := range opts.Order {
+			key, err := json.Marshal(fields.getters[o.Column](last).Interface())
+			if err != nil {
+				return "", err
+			}
+
+			next.Keys = append(next.Keys, key)
+		}
+	}
+
+	data, err := json.Marshal(next)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageToken(token string) (*pageToken, error) {
+	result := &pageToken{}
+
+	if token == "" {
+		return result, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token: %w", err)
+	}
+
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token: %w", err)
+	}
+
+	return result, nil
+}
+
+func quoteColumn(column string) string {
+	return pgx.Identifier{column}.Sanitize()
+}
@@ -0,0 +1,236 @@
+package pg
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type pageUser struct {
+	ID   int    `pg:"id"`
+	Name string `pg:"name"`
+}
+
+func encodePageToken(t *testing.T, token *pageToken) string {
+	t.Helper()
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestGetPageSql(t *testing.T) {
+	modelType := reflect.TypeOf(pageUser{})
+
+	fields, err := parseModel(modelType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseSql := "SELECT * FROM users WHERE org = $1"
+
+	tests := []struct {
+		name     string
+		opts     *PageOptions
+		token    *pageToken
+		want     string
+		wantArgs []any
+		wantErr  bool
+	}{
+		{
+			name:     "first page",
+			opts:     &PageOptions{Order: []Order{Asc("id")}, Limit: 10},
+			token:    &pageToken{},
+			want:     `SELECT * FROM (SELECT * FROM users WHERE org = $1) AS pg_page ORDER BY "id" LIMIT 11`,
+			wantArgs: []any{7},
+		},
+		{
+			name:  "keyset with mixed order",
+			opts:  &PageOptions{Order: []Order{Desc("name"), Asc("id")}, Limit: 10},
+			token: &pageToken{Keys: []json.RawMessage{json.RawMessage(`"bob"`), json.RawMessage(`42`)}},
+			want: `SELECT * FROM (SELECT * FROM users WHERE org = $1) AS pg_page` +
+				` WHERE ("name" < $2) OR ("name" = $2 AND "id" > $3)` +
+				` ORDER BY "name" DESC, "id" LIMIT 11`,
+			wantArgs: []any{7, "bob", 42},
+		},
+		{
+			name:     "offset",
+			opts:     &PageOptions{Order: []Order{Asc("id")}, Limit: 10, Offset: true},
+			token:    &pageToken{Offset: 20},
+			want:     `SELECT * FROM (SELECT * FROM users WHERE org = $1) AS pg_page ORDER BY "id" LIMIT 11 OFFSET 20`,
+			wantArgs: []any{7},
+		},
+		{
+			name:     "offset without order",
+			opts:     &PageOptions{Limit: 5, Offset: true},
+			token:    &pageToken{},
+			want:     `SELECT * FROM (SELECT * FROM users WHERE org = $1) AS pg_page LIMIT 6`,
+			wantArgs: []any{7},
+		},
+		{
+			name:    "token does not match order",
+			opts:    &PageOptions{Order: []Order{Desc("name"), Asc("id")}, Limit: 10},
+			token:   &pageToken{Keys: []json.RawMessage{json.RawMessage(`42`)}},
+			wantErr: true,
+		},
+		{
+			name:    "token value of wrong type",
+			opts:    &PageOptions{Order: []Order{Asc("id")}, Limit: 10},
+			token:   &pageToken{Keys: []json.RawMessage{json.RawMessage(`"bob"`)}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := getPageSql(baseSql, []any{7}, tt.opts, tt.token, fields, modelType)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got sql %q", sql)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if sql != tt.want {
+				t.Errorf("sql:\n got %s\nwant %s", sql, tt.want)
+			}
+
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args: got %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestGetNextPageToken(t *testing.T) {
+	fields, err := parseModel(reflect.TypeOf(pageUser{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := []pageUser{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}}
+	ptrs := []*pageUser{&users[0], &users[1]}
+
+	tests := []struct {
+		name  string
+		dest  any
+		opts  *PageOptions
+		token *pageToken
+		want  *pageToken
+	}{
+		{
+			name:  "keyset",
+			dest:  users,
+			opts:  &PageOptions{Order: []Order{Desc("name"), Asc("id")}, Limit: 2},
+			token: &pageToken{},
+			want:  &pageToken{Keys: []json.RawMessage{json.RawMessage(`"bob"`), json.RawMessage(`2`)}},
+		},
+		{
+			name:  "keyset of pointers",
+			dest:  ptrs,
+			opts:  &PageOptions{Order: []Order{Asc("id")}, Limit: 2},
+			token: &pageToken{},
+			want:  &pageToken{Keys: []json.RawMessage{json.RawMessage(`2`)}},
+		},
+		{
+			name:  "offset",
+			dest:  users,
+			opts:  &PageOptions{Limit: 2, Offset: true},
+			token: &pageToken{Offset: 4},
+			want:  &pageToken{Offset: 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := getNextPageToken(reflect.ValueOf(tt.dest), tt.opts, tt.token, fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := decodePageToken(next)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePageToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		want    *pageToken
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			token: "",
+			want:  &pageToken{},
+		},
+		{
+			name:  "offset",
+			token: encodePageToken(t, &pageToken{Offset: 10}),
+			want:  &pageToken{Offset: 10},
+		},
+		{
+			name:  "keys",
+			token: encodePageToken(t, &pageToken{Keys: []json.RawMessage{json.RawMessage(`1`)}}),
+			want:  &pageToken{Keys: []json.RawMessage{json.RawMessage(`1`)}},
+		},
+		{
+			name:    "not base64",
+			token:   "!!!",
+			wantErr: true,
+		},
+		{
+			name:    "not json",
+			token:   base64.RawURLEncoding.EncodeToString([]byte("{")),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePageToken(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got %+v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageNilOptions(t *testing.T) {
+	var users []pageUser
+
+	_, err := (&client{}).Query("SELECT * FROM users", &users).Page(context.Background(), nil)
+	if err == nil {
+		t.Error("want error")
+	}
+}
@@ -3,104 +3,178 @@ package pg
 import (
 	"context"
 	"errors"
+	"maps"
 	"reflect"
-	"slices"
+
+	"github.com/jackc/pgx/v5"
 )
 
+// Query is immutable: methods which configure it return a copy, so it can be built once
+// and executed many times. Copies share dest, so concurrent executions need their own dest set with Into.
 type Query interface {
 	WithArgs(args ...*Argument) Query
 	WithArg(key string, value any) Query
+	Into(dest any) Query
+	Prepared(name string) Query
 	Exec(ctx context.Context) error
+	Each(ctx context.Context, fn func() error) error
+	Page(ctx context.Context, opts *PageOptions) (*PageResult, error)
 }
 
 type query struct {
 	client *client
 	sql    string
+	name   string
 	dest   reflect.Value
 	args   map[string]any
 }
 
+func (q *query) clone() *query {
+	cp := *q
+	cp.args = maps.Clone(q.args)
+
+	return &cp
+}
+
 func (q *query) WithArgs(args ...*Argument) Query {
+	cp := q.clone()
+
 	for _, a := range args {
-		q.args[a.key] = a.value
+		cp.args[a.key] = a.value
 	}
 
-	return q
+	return cp
 }
 
 func (q *query) WithArg(key string, value any) Query {
-	q.args[key] = value
+	cp := q.clone()
+	cp.args[key] = value
 
-	return q
+	return cp
 }
 
-var validQueryDestKinds = []reflect.Kind{reflect.Struct, reflect.Array, reflect.Slice}
+// Into returns the copy of the query with another dest.
+func (q *query) Into(dest any) Query {
+	cp := q.clone()
+	cp.dest = reflect.ValueOf(dest)
+
+	return cp
+}
+
+// Prepared returns the copy of the query which is prepared on the server under the name
+// before the first execution on each connection.
+func (q *query) Prepared(name string) Query {
+	cp := q.clone()
+	cp.name = name
+
+	return cp
+}
 
 func (q *query) Exec(ctx context.Context) error {
-	if q.dest.Kind() != reflect.Pointer {
-		return errors.New("dest must be pointer")
+	dest, err := q.getDest()
+	if err != nil {
+		return err
 	}
 
-	q.dest = q.dest.Elem()
+	return q.withRunner(ctx, func(r runner) error {
+		rows, err := q.query(ctx, r, dest)
+		if err != nil {
+			return err
+		}
+
+		return q.client.mapRowsToDest(rows, dest, q.sql)
+	})
+}
 
-	if !slices.Contains(validQueryDestKinds, q.dest.Kind()) || slices.Contains(specificTypes, q.dest.Type()) {
-		return errors.New("dest must be struct or array")
+// Each maps the rows into dest one by one and calls fn after each of them,
+// so the whole result is never held in memory. Dest must not be a slice.
+// If fn returns an error, the iteration stops and the error is returned.
+func (q *query) Each(ctx context.Context, fn func() error) error {
+	dest, err := q.getDest()
+	if err != nil {
+		return err
 	}
 
-	if q.dest.Kind() != reflect.Struct {
-		elemType := q.dest.Type().Elem()
+	if isListType(dest.Type()) {
+		return errors.New("dest must not be slice")
+	}
 
-		if elemType.Kind() == reflect.Pointer {
-			elemType = elemType.Elem()
+	return q.withRunner(ctx, func(r runner) error {
+		rows, err := q.query(ctx, r, dest)
+		if err != nil {
+			return err
 		}
+		defer rows.Close()
+
+		mapRow := q.client.newRowMapper(dest.Type(), q.sql)
+
+		for rows.Next() {
+			err := mapRow(rows, dest)
+			if err != nil {
+				return err
+			}
 
-		if elemType.Kind() != reflect.Struct {
-			return errors.New("dest must be struct or array")
+			err = fn()
+			if err != nil {
+				return err
+			}
 		}
+
+		return wrapError(rows.Err())
+	})
+}
+
+func (q *query) getDest() (reflect.Value, error) {
+	if q.dest.Kind() != reflect.Pointer || q.dest.IsNil() {
+		return reflect.Value{}, errors.New("dest must be non-nil pointer")
 	}
 
-	var destType reflect.Type
+	return q.dest.Elem(), nil
+}
 
-	if q.dest.Kind() == reflect.Struct {
-		destType = q.dest.Type()
-	} else {
-		if q.dest.Type().Elem().Kind() == reflect.Pointer {
-			destType = q.dest.Type().Elem().Elem()
-		} else {
-			destType = q.dest.Type().Elem()
-		}
+// withRunner calls fn with the runner for the query. Prepared queries need a single connection,
+// which is held until fn returns.
+func (q *query) withRunner(ctx context.Context, fn func(r runner) error) error {
+	if q.name == "" {
+		return fn(q.client.getQueryManager(ctx))
 	}
 
-	err := q.client.registerModel(destType)
+	return q.client.withConn(ctx, func(conn *pgx.Conn) error {
+		return fn(conn)
+	})
+}
+
+func (q *query) query(ctx context.Context, r runner, dest reflect.Value) (pgx.Rows, error) {
+	sql, sqlArgs, err := q.build(dest)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	sqlFunc, err := q.client.getSqlFunc(destType, q.sql)
+	sql, err = prepare(ctx, r, q.name, sql)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	sql, sqlArgs, err := sqlFunc(q.dest, q.args)
+	rows, err := r.Query(ctx, sql, sqlArgs...)
 	if err != nil {
-		return err
+		return nil, wrapError(err)
 	}
 
-	qm := q.getQueryManager(ctx)
+	return rows, nil
+}
 
-	rows, err := qm.Query(ctx, sql, sqlArgs...)
+func (q *query) build(dest reflect.Value) (string, []any, error) {
+	destType := getDestModelType(dest.Type())
+
+	err := q.client.registerModel(destType)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
-	return q.client.mapRowsToDest(rows, q.dest)
-}
-
-func (q *query) getQueryManager(ctx context.Context) queryManager {
-	tx, ok := getTxFromContext(ctx)
-	if ok {
-		return tx
+	sqlFunc, err := q.client.getSqlFunc(destType, q.sql)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return q.client.pool
+	return sqlFunc(dest, q.args)
 }
@@ -0,0 +1,38 @@
+package pg
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryNilDest(t *testing.T) {
+	c := &client{}
+
+	tests := []struct {
+		name string
+		dest any
+	}{
+		{"nil", nil},
+		{"typed nil pointer", (*valuesUser)(nil)},
+		{"typed nil slice pointer", (*[]valuesUser)(nil)},
+		{"not pointer", valuesUser{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := c.Query("SELECT * FROM users", tt.dest)
+
+			if err := q.Exec(context.Background()); err == nil {
+				t.Error("Exec: want error")
+			}
+
+			if err := q.Each(context.Background(), func() error { return nil }); err == nil {
+				t.Error("Each: want error")
+			}
+
+			if _, err := q.Page(context.Background(), &PageOptions{Limit: 10, Offset: true}); err == nil {
+				t.Error("Page: want error")
+			}
+		})
+	}
+}
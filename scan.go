@@ -0,0 +1,177 @@
+package pg
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type rowMapper = func(rows pgx.Rows, dest reflect.Value) error
+
+// newRowMapper returns the func which maps a row into the value of destType.
+// For models the scan plan is built on the first row and reused for the next ones.
+func (c *client) newRowMapper(destType reflect.Type, sql string) rowMapper {
+	if destType.Kind() == reflect.Map {
+		return scanMap
+	}
+
+	if !isModelType(destType) {
+		return scanValue
+	}
+
+	var plan *scanPlan
+
+	return func(rows pgx.Rows, dest reflect.Value) error {
+		if plan == nil {
+			fields, err := c.getModelFields(destType)
+			if err != nil {
+				return err
+			}
+
+			plan = newScanPlan(rows.FieldDescriptions(), fields, destType, sql)
+		}
+
+		return plan.scan(rows, dest)
+	}
+}
+
+// scanPlan scans the columns of the result straight into the fields of the model.
+// Columns without fields are skipped.
+type scanPlan struct {
+	modelType reflect.Type
+	sql       string
+	fields    *modelFields
+	columns   []scanColumn
+	// keys are the indexes of the fields of the columns, -1 for columns without fields.
+	keys  []int
+	dests []any
+}
+
+type scanColumn struct {
+	name string
+	typ  reflect.Type
+	// zeroOnNull is set for fields which can not hold NULL. pgx can`t scan NULL into them,
+	// so for NULL the column is skipped and the field is set to the zero value.
+	zeroOnNull bool
+}
+
+func newScanPlan(descriptions []pgconn.FieldDescription, fields *modelFields, modelType reflect.Type, sql string) *scanPlan {
+	plan := &scanPlan{
+		modelType: modelType,
+		sql:       sql,
+		fields:    fields,
+		columns:   make([]scanColumn, len(descriptions)),
+		dests:     make([]any, len(descriptions)),
+	}
+
+	names := make([]string, len(descriptions))
+
+	for i, d := range descriptions {
+		names[i] = d.Name
+
+		typ, ok := fields.types[d.Name]
+
+		plan.columns[i] = scanColumn{
+			name:       d.Name,
+			typ:        typ,
+			zeroOnNull: ok && !isNullable(typ),
+		}
+	}
+
+	plan.keys = fields.indexes(names)
+
+	return plan
+}
+
+func (p *scanPlan) scan(rows pgx.Rows, model reflect.Value) error {
+	p.fields.targets(model, p.keys, p.dests)
+
+	raw := rows.RawValues()
+
+	for i, column := range p.columns {
+		if column.zeroOnNull && raw[i] == nil {
+			reflect.ValueOf(p.dests[i]).Elem().SetZero()
+			p.dests[i] = nil
+		}
+	}
+
+	err := rows.Scan(p.dests...)
+	if err != nil {
+		return p.scanError(rows, err)
+	}
+
+	return nil
+}
+
+func (p *scanPlan) scanError(rows pgx.Rows, err error) error {
+	var argErr pgx.ScanArgError
+	if !errors.As(err, &argErr) || argErr.ColumnIndex >= len(p.columns) {
+		return err
+	}
+
+	column := p.columns[argErr.ColumnIndex]
+
+	scanErr := &ScanError{
+		Column: column.name,
+		Model:  p.modelType,
+		Dest:   column.typ,
+		SQL:    p.sql,
+		err:    argErr.Err,
+	}
+
+	values, valuesErr := rows.Values()
+	if valuesErr == nil {
+		scanErr.Src = reflect.TypeOf(values[argErr.ColumnIndex])
+	}
+
+	return scanErr
+}
+
+// isNullable reports whether pgx can scan NULL into the value of the type itself.
+func isNullable(t reflect.Type) bool {
+	return t.Kind() == reflect.Pointer ||
+		t.Kind() == reflect.Interface ||
+		reflect.PointerTo(t).Implements(scannerType)
+}
+
+var mapType = reflect.TypeFor[map[string]any]()
+
+// scanMap sets dest to a map with the values of the row keyed by column names.
+func scanMap(rows pgx.Rows, dest reflect.Value) error {
+	if dest.Type() != mapType {
+		return errors.New("map dest must be map[string]any")
+	}
+
+	values, err := rows.Values()
+	if err != nil {
+		return err
+	}
+
+	descriptions := rows.FieldDescriptions()
+	row := make(map[string]any, len(descriptions))
+
+	for i := range descriptions {
+		row[descriptions[i].Name] = values[i]
+	}
+
+	dest.Set(reflect.ValueOf(row))
+
+	return nil
+}
+
+// scanValue scans the single column of the row into dest. NULL sets dest to zero value, as for model fields.
+func scanValue(rows pgx.Rows, dest reflect.Value) error {
+	if len(rows.FieldDescriptions()) != 1 {
+		return errors.New("result must have one column for non-struct dest")
+	}
+
+	if rows.RawValues()[0] == nil && !isNullable(dest.Type()) {
+		dest.SetZero()
+
+		return nil
+	}
+
+	return rows.Scan(dest.Addr().Interface())
+}
@@ -0,0 +1,366 @@
+package pg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeRows returns the same binary encoded row until limit rows are read, scanning like pgx does.
+type fakeRows struct {
+	typeMap      *pgtype.Map
+	descriptions []pgconn.FieldDescription
+	raw          [][]byte
+	limit        int
+	read         int
+}
+
+type fakeColumn struct {
+	name  string
+	oid   uint32
+	value any
+}
+
+func newFakeRows(t testing.TB, limit int, columns ...fakeColumn) *fakeRows {
+	rows := &fakeRows{typeMap: pgtype.NewMap(), limit: limit}
+
+	for _, c := range columns {
+		rows.descriptions = append(rows.descriptions, pgconn.FieldDescription{
+			Name:        c.name,
+			DataTypeOID: c.oid,
+			Format:      pgtype.BinaryFormatCode,
+		})
+
+		var raw []byte
+
+		if c.value != nil {
+			var err error
+
+			raw, err = rows.typeMap.Encode(c.oid, pgtype.BinaryFormatCode, c.value, nil)
+			if err != nil {
+				t.Fatalf("encode %s: %v", c.name, err)
+			}
+		}
+
+		rows.raw = append(rows.raw, raw)
+	}
+
+	return rows
+}
+
+func (r *fakeRows) Close()                                       {}
+func (r *fakeRows) Err() error                                   { return nil }
+func (r *fakeRows) CommandTag() pgconn.CommandTag                { return pgconn.NewCommandTag("SELECT") }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return r.descriptions }
+func (r *fakeRows) RawValues() [][]byte                          { return r.raw }
+func (r *fakeRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *fakeRows) Next() bool {
+	if r.limit >= 0 && r.read >= r.limit {
+		return false
+	}
+
+	r.read++
+
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	if len(dest) != len(r.raw) {
+		return errors.New("wrong number of dest")
+	}
+
+	for i, d := range dest {
+		if d == nil {
+			continue
+		}
+
+		fd := r.descriptions[i]
+
+		err := r.typeMap.Scan(fd.DataTypeOID, fd.Format, r.raw[i], d)
+		if err != nil {
+			return pgx.ScanArgError{ColumnIndex: i, FieldName: fd.Name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeRows) Values() ([]any, error) {
+	values := make([]any, len(r.raw))
+
+	for i, raw := range r.raw {
+		if raw == nil {
+			continue
+		}
+
+		fd := r.descriptions[i]
+
+		t, ok := r.typeMap.TypeForOID(fd.DataTypeOID)
+		if !ok {
+			return nil, errors.New("unknown oid")
+		}
+
+		v, err := t.Codec.DecodeValue(r.typeMap, fd.DataTypeOID, fd.Format, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+type scanUser struct {
+	ID      int64     `pg:"id"`
+	Name    string    `pg:"name"`
+	Note    *string   `pg:"note"`
+	Score   float64   `pg:"score"`
+	Active  bool      `pg:"active"`
+	Created time.Time `pg:"created"`
+}
+
+var scanCreated = time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+
+func scanUserColumns() []fakeColumn {
+	return []fakeColumn{
+		{"id", pgtype.Int8OID, int64(1)},
+		{"name", pgtype.TextOID, "admin"},
+		{"note", pgtype.TextOID, "note"},
+		{"score", pgtype.Float8OID, 1.5},
+		{"active", pgtype.BoolOID, true},
+		{"created", pgtype.TimestamptzOID, scanCreated},
+	}
+}
+
+func TestScanPlan(t *testing.T) {
+	c := &client{}
+	note := "note"
+
+	rows := newFakeRows(t, 1, append(scanUserColumns(), fakeColumn{"unknown", pgtype.Int4OID, int32(5)})...)
+
+	var u scanUser
+
+	err := c.mapRowsToDest(rows, reflect.ValueOf(&u).Elem(), "sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := scanUser{ID: 1, Name: "admin", Note: &note, Score: 1.5, Active: true, Created: scanCreated}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("model = %+v, want %+v", u, want)
+	}
+}
+
+func TestScanPlanNull(t *testing.T) {
+	c := &client{}
+
+	rows := newFakeRows(t, 1,
+		fakeColumn{"id", pgtype.Int8OID, nil},
+		fakeColumn{"name", pgtype.TextOID, nil},
+		fakeColumn{"note", pgtype.TextOID, nil},
+		fakeColumn{"created", pgtype.TimestamptzOID, nil},
+	)
+
+	note := "old"
+	u := scanUser{ID: 5, Name: "old", Note: &note, Created: scanCreated}
+
+	err := c.newRowMapper(reflect.TypeFor[scanUser](), "sql")(rows, reflect.ValueOf(&u).Elem())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(u, scanUser{}) {
+		t.Errorf("model = %+v, want zero value", u)
+	}
+}
+
+func TestScanPlanError(t *testing.T) {
+	c := &client{}
+
+	rows := newFakeRows(t, 1, fakeColumn{"id", pgtype.TextOID, "abc"})
+
+	var u scanUser
+
+	err := c.newRowMapper(reflect.TypeFor[scanUser](), "SELECT 'abc' AS id")(rows, reflect.ValueOf(&u).Elem())
+
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("error = %v, want *ScanError", err)
+	}
+
+	if scanErr.Column != "id" || scanErr.Dest != reflect.TypeFor[int64]() || scanErr.Src != reflect.TypeFor[string]() || scanErr.SQL != "SELECT 'abc' AS id" {
+		t.Errorf("error = %+v", scanErr)
+	}
+
+	if errors.Unwrap(err) == nil {
+		t.Error("error must wrap the pgx error")
+	}
+}
+
+func TestScanValue(t *testing.T) {
+	var n int64 = 5
+
+	err := scanValue(newFakeRows(t, 1, fakeColumn{"n", pgtype.Int8OID, nil}), reflect.ValueOf(&n).Elem())
+	if err != nil || n != 0 {
+		t.Errorf("scan NULL: n = %d, err = %v", n, err)
+	}
+
+	err = scanValue(newFakeRows(t, 1, fakeColumn{"n", pgtype.Int8OID, int64(7)}), reflect.ValueOf(&n).Elem())
+	if err != nil || n != 7 {
+		t.Errorf("scan 7: n = %d, err = %v", n, err)
+	}
+}
+
+func BenchmarkScanRow(b *testing.B) {
+	modelType := reflect.TypeFor[scanUser]()
+
+	fields, err := parseModel(modelType)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// values is the mapping before the scan plan: rows.Values and a setter per column,
+	// built with reflect.MakeFunc as it was.
+	b.Run("values", func(b *testing.B) {
+		paths, err := getPaths(modelType, "", []int{})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		setters := make(map[string]valuesSetter, len(paths))
+		for k, path := range paths {
+			setters[k] = getValuesSetter(path)
+		}
+
+		rows := newFakeRows(b, -1, scanUserColumns()...)
+		model := reflect.New(modelType).Elem()
+
+		b.ReportAllocs()
+
+		for b.Loop() {
+			values, err := rows.Values()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			for i, d := range rows.FieldDescriptions() {
+				setter, ok := setters[d.Name]
+				if !ok {
+					continue
+				}
+
+				if err := setter(model, reflect.ValueOf(values[i])); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	// holders is the scan into a pointer per field, which is copied to the field after the scan.
+	b.Run("holders", func(b *testing.B) {
+		rows := newFakeRows(b, -1, scanUserColumns()...)
+		model := reflect.New(modelType).Elem()
+		descriptions := rows.FieldDescriptions()
+		holders := make([]reflect.Value, len(descriptions))
+		dests := make([]any, len(descriptions))
+
+		for i, d := range descriptions {
+			if typ := fields.types[d.Name]; !isNullable(typ) {
+				holders[i] = reflect.New(reflect.PointerTo(typ))
+			}
+		}
+
+		b.ReportAllocs()
+
+		for b.Loop() {
+			for i, d := range descriptions {
+				if holders[i].IsValid() {
+					holders[i].Elem().SetZero()
+					dests[i] = holders[i].Interface()
+				} else {
+					dests[i] = fields.getters[d.Name](model).Addr().Interface()
+				}
+			}
+
+			if err := rows.Scan(dests...); err != nil {
+				b.Fatal(err)
+			}
+
+			for i, d := range descriptions {
+				if !holders[i].IsValid() {
+					continue
+				}
+
+				field := fields.getters[d.Name](model)
+				if holders[i].Elem().IsNil() {
+					field.SetZero()
+				} else {
+					field.Set(holders[i].Elem().Elem())
+				}
+			}
+		}
+	})
+
+	b.Run("plan", func(b *testing.B) {
+		rows := newFakeRows(b, -1, scanUserColumns()...)
+		model := reflect.New(modelType).Elem()
+		mapRow := (&client{}).newRowMapper(modelType, "sql")
+
+		b.ReportAllocs()
+
+		for b.Loop() {
+			if err := mapRow(rows, model); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type valuesSetter = func(model reflect.Value, value reflect.Value) error
+
+// getValuesSetter returns the setter of the mapping before the scan plan, which converted
+// the values of rows.Values to the field type. Pointer fields, which it could not set, are wrapped here.
+func getValuesSetter(indexPath []int) valuesSetter {
+	setterType := reflect.FuncOf(
+		[]reflect.Type{reflect.TypeFor[reflect.Value](), reflect.TypeFor[reflect.Value]()},
+		[]reflect.Type{reflect.TypeFor[error]()},
+		false,
+	)
+
+	base := func(args []reflect.Value) []reflect.Value {
+		model := args[0].Interface().(reflect.Value)
+		value := args[1].Interface().(reflect.Value)
+
+		field := model.FieldByIndex(indexPath)
+
+		var err error
+
+		switch {
+		case !value.IsValid():
+			field.SetZero()
+		case field.Kind() == reflect.Pointer && value.CanConvert(field.Type().Elem()):
+			p := reflect.New(field.Type().Elem())
+			p.Elem().Set(value.Convert(field.Type().Elem()))
+			field.Set(p)
+		case value.CanConvert(field.Type()):
+			field.Set(value.Convert(field.Type()))
+		default:
+			err = errors.New("invalid value")
+		}
+
+		if err != nil {
+			return []reflect.Value{reflect.ValueOf(err)}
+		}
+
+		return []reflect.Value{reflect.Zero(reflect.TypeFor[error]())}
+	}
+
+	return reflect.MakeFunc(setterType, base).Interface().(valuesSetter)
+}
@@ -4,63 +4,65 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 )
 
 type sqlFunc = func(model reflect.Value, args map[string]any) (sql string, sqlArgs []any, err error)
 
-func getSqlFunc(sql string, keys []valueKey, modelMeta *modelFields) sqlFunc {
-	base := getSqlFuncBase(sql, keys, modelMeta)
+func getSqlFunc(rawSql string, sql string, keys []valueKey, modelMeta *modelFields) sqlFunc {
+	modelKeys := []string{}
 
-	sqlFuncIn := []reflect.Type{reflect.TypeFor[reflect.Value](), reflect.TypeFor[map[string]any]()}
-	sqlFuncOut := []reflect.Type{reflect.TypeFor[string](), reflect.TypeFor[[]any](), reflect.TypeFor[error]()}
-	sqlFuncType := reflect.FuncOf(sqlFuncIn, sqlFuncOut, false)
+	for _, k := range keys {
+		if k.isModel {
+			modelKeys = append(modelKeys, k.key)
+		}
+	}
 
-	return reflect.MakeFunc(sqlFuncType, base).Interface().(sqlFunc)
-}
+	modelIndexes := modelMeta.indexes(modelKeys)
+	missing := slices.Index(modelIndexes, -1)
+
+	return func(model reflect.Value, args map[string]any) (string, []any, error) {
+		var modelArgs []any
+
+		if len(modelKeys) != 0 {
+			if model.Kind() != reflect.Struct {
+				return "", nil, errors.New("can`t use non-struct value as src for sql")
+			}
+
+			if missing >= 0 {
+				return "", nil, &FieldNotFoundError{
+					Key:   modelKeys[missing],
+					Model: model.Type(),
+					SQL:   rawSql,
+				}
+			}
 
-func getSqlFuncBase(sql string, keys []valueKey, modelMeta *modelFields) fnBase {
-	return func(args []reflect.Value) (results []reflect.Value) {
-		model := args[0].Interface().(reflect.Value)
-		valueArgs := args[1].Interface().(map[string]any)
+			modelArgs = modelMeta.args(model, modelIndexes, make([]any, 0, len(modelIndexes)))
+		}
 
-		sqlArgs := []any{}
-		var err error = nil
+		sqlArgs := make([]any, 0, len(keys))
 
 		for _, k := range keys {
 			if k.isModel {
-				if model.Kind() != reflect.Struct {
-					err = errors.New("can`t use array as src for sql")
-				}
+				sqlArgs = append(sqlArgs, modelArgs[0])
+				modelArgs = modelArgs[1:]
 
-				getter, ok := modelMeta.getters[k.key]
-				if ok {
-					sqlArgs = append(sqlArgs, getter(model).Interface())
-				} else {
-					err = errors.New("model field not found")
-					break
-				}
-			} else {
-				value, ok := valueArgs[k.key]
-				if ok {
-					sqlArgs = append(sqlArgs, value)
-				} else {
-					err = errors.New("arg not found")
-					break
-				}
+				continue
 			}
-		}
 
-		results = append(results, reflect.ValueOf(sql))
-		results = append(results, reflect.ValueOf(sqlArgs))
+			value, ok := args[k.key]
+			if !ok {
+				return "", nil, &ArgNotFoundError{
+					Key: k.key,
+					SQL: rawSql,
+				}
+			}
 
-		if err != nil {
-			results = append(results, reflect.ValueOf(err))
-		} else {
-			results = append(results, reflect.Zero(reflect.TypeOf((*error)(nil)).Elem()))
+			sqlArgs = append(sqlArgs, value)
 		}
 
-		return results
+		return sql, sqlArgs, nil
 	}
 }
 
@@ -69,57 +71,550 @@ type valueKey struct {
 	isModel bool
 }
 
-const ch = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-
+// extractKeys replaces the "@" and "#" keys of the sql with positional parameters.
+// Repeated keys share the same parameter, so keys contains every key once.
 func extractKeys(sql string) (string, []valueKey, error) {
-	collectKey := false
-	collectedKey := ""
+	tokens, err := lexSql(sql)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
 	keys := []valueKey{}
-	toReplace := []string{}
+	positions := make(map[valueKey]int)
 
-	for i, s := range sql {
-		symb := string(s)
+	for _, t := range tokens {
+		if t.kind != keyToken {
+			sb.WriteString(t.text)
+			continue
+		}
 
-		if symb == "@" || symb == "#" {
-			if collectKey {
-				return "", nil, errors.New("invalid key")
-			} else {
-				collectKey = true
-				collectedKey = symb
-			}
+		pos, ok := positions[t.key]
+		if !ok {
+			keys = append(keys, t.key)
+			pos = len(keys)
+			positions[t.key] = pos
+		}
+
+		sb.WriteString(fmt.Sprintf("$%d", pos))
+	}
+
+	return sb.String(), keys, nil
+}
+
+type tokenKind int
+
+const (
+	// codeToken is a part of the sql which is not a key, literal or comment.
+	codeToken tokenKind = iota
+	// quotedToken is a string literal, quoted identifier, dollar-quoted string or comment.
+	quotedToken
+	// keyToken is an "@" or "#" key.
+	keyToken
+)
+
+type sqlToken struct {
+	kind tokenKind
+	text string
+	key  valueKey
+}
+
+// lexSql splits the sql into tokens. Keys are only recognized in code, so "@" and "#"
+// in literals, comments and operators like @>, <@, #> or @@ are kept as is.
+func lexSql(sql string) ([]sqlToken, error) {
+	l := &sqlLexer{sql: sql}
+
+	for l.pos < len(sql) {
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	l.flushCode(len(sql))
+
+	return l.tokens, nil
+}
+
+type sqlLexer struct {
+	sql       string
+	pos       int
+	codeStart int
+	tokens    []sqlToken
+}
+
+func (l *sqlLexer) next() error {
+	start := l.pos
+	c := l.sql[start]
+
+	switch {
+	case c == '\'':
+		escapes := start > 0 && (l.sql[start-1] == 'E' || l.sql[start-1] == 'e') &&
+			(start < 2 || !isIdentChar(l.sql[start-2]))
+
+		end, err := l.skipString('\'', escapes)
+		if err != nil {
+			return err
+		}
+
+		l.quoted(start, end)
+		return nil
+	case c == '"':
+		end, err := l.skipString('"', false)
+		if err != nil {
+			return err
+		}
+
+		l.quoted(start, end)
+		return nil
+	case c == '-' && l.peek(1) == '-':
+		end := strings.IndexByte(l.sql[start:], '\n')
+		if end < 0 {
+			l.quoted(start, len(l.sql))
 		} else {
-			if collectKey {
-				if strings.Contains(ch, symb) {
-					collectedKey += symb
+			l.quoted(start, start+end+1)
+		}
+
+		return nil
+	case c == '/' && l.peek(1) == '*':
+		end, err := l.skipBlockComment()
+		if err != nil {
+			return err
+		}
+
+		l.quoted(start, end)
+		return nil
+	case c == '$' && (start == 0 || !isIdentChar(l.sql[start-1])):
+		tag, ok := l.dollarTag()
+		if !ok {
+			l.pos++
+			return nil
+		}
+
+		end := strings.Index(l.sql[start+len(tag):], tag)
+		if end < 0 {
+			return errors.New("unterminated dollar-quoted string")
+		}
+
+		l.quoted(start, start+len(tag)+end+len(tag))
+		return nil
+	case (c == '@' || c == '#') && isKeyStart(l.peek(1)) && !l.inOperator():
+		l.key()
+		return nil
+	default:
+		l.pos++
+		return nil
+	}
+}
+
+func (l *sqlLexer) peek(offset int) byte {
+	if l.pos+offset >= len(l.sql) {
+		return 0
+	}
+
+	return l.sql[l.pos+offset]
+}
+
+func (l *sqlLexer) flushCode(end int) {
+	if end > l.codeStart {
+		l.tokens = append(l.tokens, sqlToken{kind: codeToken, text: l.sql[l.codeStart:end]})
+	}
+}
+
+func (l *sqlLexer) quoted(start int, end int) {
+	l.flushCode(start)
+	l.tokens = append(l.tokens, sqlToken{kind: quotedToken, text: l.sql[start:end]})
+	l.pos = end
+	l.codeStart = end
+}
+
+func (l *sqlLexer) key() {
+	start := l.pos
+	end := start + 2
+
+	for end < len(l.sql) && isKeyChar(l.sql[end]) {
+		if l.sql[end] == '.' && l.sql[end-1] == '.' {
+			break
+		}
+
+		end++
+	}
+
+	for l.sql[end-1] == '.' {
+		end--
+	}
+
+	l.flushCode(start)
+	l.tokens = append(l.tokens, sqlToken{
+		kind: keyToken,
+		text: l.sql[start:end],
+		key: valueKey{
+			key:     l.sql[start+1 : end],
+			isModel: l.sql[start] == '@',
+		},
+	})
+	l.pos = end
+	l.codeStart = end
+}
+
+// skipString returns the end of the literal which starts at the current position.
+// Doubled quotes are always escaped, backslashes only in E-prefixed strings.
+func (l *sqlLexer) skipString(quote byte, escapes bool) (int, error) {
+	for i := l.pos + 1; i < len(l.sql); i++ {
+		switch {
+		case escapes && l.sql[i] == '\\':
+			i++
+		case l.sql[i] == quote:
+			if i+1 < len(l.sql) && l.sql[i+1] == quote {
+				i++
+				continue
+			}
+
+			return i + 1, nil
+		}
+	}
+
+	return 0, errors.New("unterminated quoted string")
+}
+
+// skipBlockComment returns the end of the comment which starts at the current position.
+// Block comments can be nested.
+func (l *sqlLexer) skipBlockComment() (int, error) {
+	depth := 0
+
+	for i := l.pos; i+1 < len(l.sql); i++ {
+		switch {
+		case l.sql[i] == '/' && l.sql[i+1] == '*':
+			depth++
+			i++
+		case l.sql[i] == '*' && l.sql[i+1] == '/':
+			depth--
+			i++
+
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+
+	return 0, errors.New("unterminated block comment")
+}
+
+// dollarTag returns the opening tag of the dollar-quoted string ($$ or $tag$)
+// which starts at the current position. Positional parameters like $1 are not tags.
+func (l *sqlLexer) dollarTag() (string, bool) {
+	start := l.pos
+
+	for i := start + 1; i < len(l.sql); i++ {
+		c := l.sql[i]
+
+		if c == '$' {
+			return l.sql[start : i+1], true
+		}
+
+		if !isIdentChar(c) || c == '$' || (i == start+1 && isDigit(c)) {
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+func isKeyStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isKeyChar reports whether c can continue the key.
+// Dots separate the names of nested fields (@address.city).
+func isKeyChar(c byte) bool {
+	return isKeyStart(c) || isDigit(c) || c == '.'
+}
+
+func isIdentChar(c byte) bool {
+	return isKeyStart(c) || isDigit(c) || c == '$' || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// inOperator reports whether "@" or "#" at the current position continues an operator
+// like @@, ## or <@. Other symbols before keys are allowed, so they can be written like "id=@id".
+func (l *sqlLexer) inOperator() bool {
+	if l.pos == 0 {
+		return false
+	}
+
+	prev := l.sql[l.pos-1]
+
+	return prev == '@' || prev == '#' || (prev == '<' && l.sql[l.pos] == '@')
+}
+
+// maxParams is the limit of parameters in one statement of PostgreSQL protocol.
+const maxParams = 65535
+
+// valuesSql is the sql of the multi-row command, split by the tuple after VALUES.
+// The tuple is repeated for every model, other parts are written once.
+type valuesSql struct {
+	prefix []sqlToken
+	tuple  []sqlToken
+	suffix []sqlToken
+
+	// rowParams is the number of parameters of the tuple for one model.
+	rowParams int
+	// argParams is the number of "#" parameters of the whole sql.
+	argParams int
+}
+
+func parseValuesSql(sql string) (*valuesSql, error) {
+	prefix, tuple, suffix, err := splitValues(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	vs := &valuesSql{}
+
+	vs.prefix, err = lexSql(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	vs.tuple, err = lexSql(tuple)
+	if err != nil {
+		return nil, err
+	}
+
+	vs.suffix, err = lexSql(suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	rowKeys := make(map[string]bool)
+	argKeys := make(map[string]bool)
+
+	for i, part := range [][]sqlToken{vs.prefix, vs.tuple, vs.suffix} {
+		inTuple := i == 1
+
+		for _, t := range part {
+			if t.kind != keyToken {
+				continue
+			}
+
+			switch {
+			case !t.key.isModel:
+				argKeys[t.key.key] = true
+			case inTuple:
+				rowKeys[t.key.key] = true
+			default:
+				return nil, errors.New("model keys of multi-row command must be in VALUES")
+			}
+		}
+	}
+
+	vs.rowParams = len(rowKeys)
+	vs.argParams = len(argKeys)
+
+	return vs, nil
+}
+
+func (vs *valuesSql) chunkSize(models int) int {
+	if vs.rowParams == 0 {
+		return max(models, 1)
+	}
+
+	return max((maxParams-vs.argParams)/vs.rowParams, 1)
+}
+
+func (vs *valuesSql) build(models []reflect.Value, fields *modelFields, args map[string]any, rawSql string) (string, []any, error) {
+	var sb strings.Builder
+	sqlArgs := []any{}
+	argPositions := make(map[string]int)
+
+	writeArg := func(key string) error {
+		pos, ok := argPositions[key]
+		if !ok {
+			value, ok := args[key]
+			if !ok {
+				return &ArgNotFoundError{
+					Key: key,
+					SQL: rawSql,
 				}
+			}
+
+			sqlArgs = append(sqlArgs, value)
+			pos = len(sqlArgs)
+			argPositions[key] = pos
+		}
 
-				if !strings.Contains(ch, symb) || i == len(sql)-1 {
-					if len(collectedKey) == 1 {
-						return "", nil, errors.New("empty key")
-					}
+		sb.WriteString(fmt.Sprintf("$%d", pos))
 
-					toReplace = append(toReplace, collectedKey)
+		return nil
+	}
+
+	writeOnce := func(tokens []sqlToken) error {
+		for _, t := range tokens {
+			if t.kind != keyToken {
+				sb.WriteString(t.text)
+				continue
+			}
 
-					key := valueKey{
-						key: collectedKey[1:],
-					}
+			if err := writeArg(t.key.key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := writeOnce(vs.prefix); err != nil {
+		return "", nil, err
+	}
 
-					if string(collectedKey[0]) == "@" {
-						key.isModel = true
-					} else {
-						key.isModel = false
-					}
+	rowKeys := []string{}
+	rowPositions := make(map[string]int)
 
-					keys = append(keys, key)
-					collectKey = false
+	for _, t := range vs.tuple {
+		if t.kind == keyToken && t.key.isModel {
+			if _, ok := rowPositions[t.key.key]; !ok {
+				rowPositions[t.key.key] = len(rowKeys)
+				rowKeys = append(rowKeys, t.key.key)
+			}
+		}
+	}
+
+	rowIndexes := fields.indexes(rowKeys)
+
+	if i := slices.Index(rowIndexes, -1); i >= 0 && len(models) != 0 {
+		return "", nil, &FieldNotFoundError{
+			Key:   rowKeys[i],
+			Model: models[0].Type(),
+			SQL:   rawSql,
+		}
+	}
+
+	for i, model := range models {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		base := len(sqlArgs)
+		sqlArgs = fields.args(model, rowIndexes, sqlArgs)
+
+		for _, t := range vs.tuple {
+			if t.kind != keyToken {
+				sb.WriteString(t.text)
+				continue
+			}
+
+			if !t.key.isModel {
+				if err := writeArg(t.key.key); err != nil {
+					return "", nil, err
+				}
+
+				continue
+			}
+
+			sb.WriteString(fmt.Sprintf("$%d", base+rowPositions[t.key.key]+1))
+		}
+	}
+
+	if err := writeOnce(vs.suffix); err != nil {
+		return "", nil, err
+	}
+
+	return sb.String(), sqlArgs, nil
+}
+
+// splitValues splits the sql into the part before the tuple after VALUES keyword,
+// the tuple with its parentheses and the rest. If the sql has several VALUES,
+// like in a subquery before the inserted rows, the first tuple with model keys is used.
+func splitValues(sql string) (string, string, string, error) {
+	tokens, err := lexSql(sql)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	type tuple struct{ open, end int }
+
+	tuples := []tuple{}
+	offset := 0
+	open := -1
+	depth := 0
+
+	for _, t := range tokens {
+		if t.kind != codeToken {
+			offset += len(t.text)
+			continue
+		}
+
+		for i := 0; i < len(t.text); i++ {
+			switch {
+			case open < 0:
+				if !isKeywordAt(t.text, i, "values") {
+					continue
+				}
+
+				j := i + len("values")
+				for j < len(t.text) && strings.IndexByte(" \t\r\n", t.text[j]) >= 0 {
+					j++
+				}
+
+				if j < len(t.text) && t.text[j] == '(' {
+					open = offset + j
+					depth = 1
+					i = j
+				}
+			case t.text[i] == '(':
+				depth++
+			case t.text[i] == ')':
+				depth--
+
+				if depth == 0 {
+					tuples = append(tuples, tuple{open: open, end: offset + i + 1})
+					open = -1
 				}
 			}
 		}
+
+		offset += len(t.text)
+	}
+
+	if len(tuples) == 0 {
+		return "", "", "", errors.New("multi-row command must have VALUES (...)")
+	}
+
+	found := tuples[0]
+
+	for _, tp := range tuples {
+		if hasModelKeys(sql[tp.open:tp.end]) {
+			found = tp
+			break
+		}
+	}
+
+	return sql[:found.open], sql[found.open:found.end], sql[found.end:], nil
+}
+
+func hasModelKeys(sql string) bool {
+	tokens, err := lexSql(sql)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(tokens, func(t sqlToken) bool {
+		return t.kind == keyToken && t.key.isModel
+	})
+}
+
+func isKeywordAt(text string, i int, keyword string) bool {
+	end := i + len(keyword)
+
+	if end > len(text) || !strings.EqualFold(text[i:end], keyword) {
+		return false
 	}
 
-	for i, k := range toReplace {
-		sql = strings.ReplaceAll(sql, k, fmt.Sprintf("$%d", i+1))
+	if i > 0 && isIdentChar(text[i-1]) {
+		return false
 	}
 
-	return sql, keys, nil
+	return end == len(text) || !isIdentChar(text[end])
 }
@@ -0,0 +1,137 @@
+package pg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+		keys []valueKey
+		err  bool
+	}{
+		{
+			name: "model and arg keys",
+			sql:  "SELECT * FROM users WHERE id = @id AND name = #name",
+			want: "SELECT * FROM users WHERE id = $1 AND name = $2",
+			keys: []valueKey{{key: "id", isModel: true}, {key: "name"}},
+		},
+		{
+			name: "string literal",
+			sql:  "SELECT * FROM users WHERE email = 'a@b.com' AND id = @id",
+			want: "SELECT * FROM users WHERE email = 'a@b.com' AND id = $1",
+			keys: []valueKey{{key: "id", isModel: true}},
+		},
+		{
+			name: "escaped quote in literal",
+			sql:  "SELECT 'it''s @x', @y",
+			want: "SELECT 'it''s @x', $1",
+			keys: []valueKey{{key: "y", isModel: true}},
+		},
+		{
+			name: "E-prefixed string",
+			sql:  `SELECT E'\'@x', #y`,
+			want: `SELECT E'\'@x', $1`,
+			keys: []valueKey{{key: "y"}},
+		},
+		{
+			name: "quoted identifier",
+			sql:  `SELECT "@x" FROM t WHERE a = @a`,
+			want: `SELECT "@x" FROM t WHERE a = $1`,
+			keys: []valueKey{{key: "a", isModel: true}},
+		},
+		{
+			name: "line comment",
+			sql:  "SELECT @a -- @b\nFROM t",
+			want: "SELECT $1 -- @b\nFROM t",
+			keys: []valueKey{{key: "a", isModel: true}},
+		},
+		{
+			name: "nested block comment",
+			sql:  "SELECT /* /* @a */ #b */ @c",
+			want: "SELECT /* /* @a */ #b */ $1",
+			keys: []valueKey{{key: "c", isModel: true}},
+		},
+		{
+			name: "dollar-quoted body",
+			sql:  "SELECT $$ @a #b $$, @c",
+			want: "SELECT $$ @a #b $$, $1",
+			keys: []valueKey{{key: "c", isModel: true}},
+		},
+		{
+			name: "tagged dollar-quoted body",
+			sql:  "SELECT $fn$ @a $$ #b $fn$, #c",
+			want: "SELECT $fn$ @a $$ #b $fn$, $1",
+			keys: []valueKey{{key: "c"}},
+		},
+		{
+			name: "positional parameter is not dollar quote",
+			sql:  "SELECT $1, @a",
+			want: "SELECT $1, $1",
+			keys: []valueKey{{key: "a", isModel: true}},
+		},
+		{
+			name: "type cast",
+			sql:  "SELECT @id::int",
+			want: "SELECT $1::int",
+			keys: []valueKey{{key: "id", isModel: true}},
+		},
+		{
+			name: "operators",
+			sql:  "SELECT a @> b, a <@ b, a #>> '{x}', a @@ b, a #> b FROM t WHERE id = @id",
+			want: "SELECT a @> b, a <@ b, a #>> '{x}', a @@ b, a #> b FROM t WHERE id = $1",
+			keys: []valueKey{{key: "id", isModel: true}},
+		},
+		{
+			name: "key prefix of another key",
+			sql:  "SELECT @id, @idx",
+			want: "SELECT $1, $2",
+			keys: []valueKey{{key: "id", isModel: true}, {key: "idx", isModel: true}},
+		},
+		{
+			name: "repeated keys",
+			sql:  "SELECT @id, #id, @id, #id",
+			want: "SELECT $1, $2, $1, $2",
+			keys: []valueKey{{key: "id", isModel: true}, {key: "id"}},
+		},
+		{
+			name: "nested key",
+			sql:  "SELECT @address.city",
+			want: "SELECT $1",
+			keys: []valueKey{{key: "address.city", isModel: true}},
+		},
+		{name: "unterminated string", sql: "SELECT 'abc", err: true},
+		{name: "unterminated E-prefixed string", sql: `SELECT E'abc\'`, err: true},
+		{name: "unterminated quoted identifier", sql: `SELECT "abc`, err: true},
+		{name: "unterminated block comment", sql: "SELECT /* /* */", err: true},
+		{name: "unterminated dollar quote", sql: "SELECT $fn$ abc $$", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, keys, err := extractKeys(tt.sql)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("extractKeys(%q) = %q, want error", tt.sql, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("extractKeys(%q) error: %v", tt.sql, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("extractKeys(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+
+			if !slices.Equal(keys, tt.keys) {
+				t.Errorf("extractKeys(%q) keys = %v, want %v", tt.sql, keys, tt.keys)
+			}
+		})
+	}
+}
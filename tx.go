@@ -2,13 +2,21 @@ package pg
 
 import (
 	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type TxFunc func(ctx context.Context) error
-type TxOption func(opts *pgx.TxOptions)
+type TxOption func(opts *txOptions)
+
+// RetryHook is called with the number of the failed attempt and its error before the next attempt.
+type RetryHook func(attempt int, err error)
 
 type TxIsoLevel = pgx.TxIsoLevel
 type TxAccessMode = pgx.TxAccessMode
@@ -28,37 +36,123 @@ const (
 )
 
 func WithLevel(level TxIsoLevel) TxOption {
-	return func(opts *pgx.TxOptions) {
-		opts.IsoLevel = level
+	return func(opts *txOptions) {
+		opts.pgx.IsoLevel = level
 	}
 }
 
 func WithAccess(access TxAccessMode) TxOption {
-	return func(opts *pgx.TxOptions) {
-		opts.AccessMode = access
+	return func(opts *txOptions) {
+		opts.pgx.AccessMode = access
 	}
 }
 
 func WithDeferrable(deferrable TxDeferrableMode) TxOption {
-	return func(opts *pgx.TxOptions) {
-		opts.DeferrableMode = deferrable
+	return func(opts *txOptions) {
+		opts.pgx.DeferrableMode = deferrable
 	}
 }
 
 func WithBeginQuery(query string) TxOption {
-	return func(opts *pgx.TxOptions) {
-		opts.BeginQuery = query
+	return func(opts *txOptions) {
+		opts.pgx.BeginQuery = query
 	}
 }
 
 func WithCommitQuery(query string) TxOption {
-	return func(opts *pgx.TxOptions) {
-		opts.CommitQuery = query
+	return func(opts *txOptions) {
+		opts.pgx.CommitQuery = query
+	}
+}
+
+// WithRetry re-runs the whole transaction when it fails with a serialization failure (40001)
+// or a deadlock (40P01). The delay between attempts grows exponentially from backoff with jitter,
+// up to 30 seconds by default, see WithRetryMaxBackoff.
+// It has no effect on nested transactions, because they can not be retried separately.
+func WithRetry(maxAttempts int, backoff time.Duration) TxOption {
+	return func(opts *txOptions) {
+		opts.retry.attempts = maxAttempts
+		opts.retry.backoff = backoff
+	}
+}
+
+// WithRetryMaxBackoff limits the delay between attempts of WithRetry.
+func WithRetryMaxBackoff(maxBackoff time.Duration) TxOption {
+	return func(opts *txOptions) {
+		opts.retry.maxBackoff = maxBackoff
+	}
+}
+
+// WithRetryHook sets the hook which is called after each failed attempt of WithRetry which will be retried.
+func WithRetryHook(hook RetryHook) TxOption {
+	return func(opts *txOptions) {
+		opts.retry.hook = hook
+	}
+}
+
+type txOptions struct {
+	pgx   pgx.TxOptions
+	retry txRetry
+}
+
+type txRetry struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	hook       RetryHook
+}
+
+func (r *txRetry) delay(attempt int) time.Duration {
+	if r.backoff <= 0 {
+		return 0
+	}
+
+	d := r.backoff << min(attempt-1, maxBackoffShift)
+	if d <= 0 || (r.maxBackoff > 0 && d > r.maxBackoff) {
+		d = r.maxBackoff
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+
+	return half + rand.N(d-half+1)
+}
+
+const (
+	maxBackoffShift   = 16
+	defaultMaxBackoff = 30 * time.Second
+)
+
+var retryableCodes = []string{"40001", "40P01"}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return slices.Contains(retryableCodes, pgErr.Code)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
 
-func getTxOptions(opts []TxOption) *pgx.TxOptions {
-	result := &pgx.TxOptions{}
+func getTxOptions(opts []TxOption) *txOptions {
+	result := &txOptions{
+		retry: txRetry{maxBackoff: defaultMaxBackoff},
+	}
 
 	for _, o := range opts {
 		o(result)
@@ -67,31 +161,170 @@ func getTxOptions(opts []TxOption) *pgx.TxOptions {
 	return result
 }
 
-type txContext struct {
-	tx   pgx.Tx
-	base context.Context
+// Tx is a transaction started with Client.Begin.
+// Query and Command use it when they get the context returned by Client.Begin.
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+	Savepoint(ctx context.Context, name string) error
+	RollbackTo(ctx context.Context, name string) error
+	ReleaseSavepoint(ctx context.Context, name string) error
+
+	ToPgx() pgx.Tx
 }
 
-func (tc *txContext) Deadline() (time.Time, bool) {
-	return tc.base.Deadline()
+// TxHook is a callback registered with OnCommit or OnRollback.
+type TxHook func(ctx context.Context)
+
+// OnCommit registers hook to run after the transaction in ctx is committed.
+// Hooks registered in a nested transaction are passed to the outer one when the savepoint is released.
+func OnCommit(ctx context.Context, hook TxHook) error {
+	tx, ok := getTransaction(ctx)
+	if !ok {
+		return ErrNoTx
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.onCommit = append(tx.onCommit, hook)
+
+	return nil
 }
 
-func (tc *txContext) Done() <-chan struct{} {
-	return tc.base.Done()
+// OnRollback registers hook to run after the transaction in ctx is rolled back.
+// For a nested transaction it runs when its savepoint, or later the outer transaction, is rolled back.
+func OnRollback(ctx context.Context, hook TxHook) error {
+	tx, ok := getTransaction(ctx)
+	if !ok {
+		return ErrNoTx
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.onRollback = append(tx.onRollback, hook)
+
+	return nil
+}
+
+type transaction struct {
+	tx     pgx.Tx
+	parent *transaction
+
+	mu         sync.Mutex
+	done       bool
+	onCommit   []TxHook
+	onRollback []TxHook
+}
+
+func (t *transaction) Commit(ctx context.Context) error {
+	if err := t.tx.Commit(ctx); err != nil {
+		// A failed commit of the outer transaction means it was rolled back.
+		if t.parent == nil {
+			t.finish(ctx, false)
+		}
+
+		return wrapError(err)
+	}
+
+	t.finish(ctx, true)
+
+	return nil
 }
 
-func (tc *txContext) Err() error {
-	return tc.base.Err()
+func (t *transaction) Rollback(ctx context.Context) error {
+	err := t.tx.Rollback(ctx)
+	if errors.Is(err, pgx.ErrTxClosed) {
+		return err
+	}
+
+	t.finish(ctx, false)
+
+	return err
 }
 
-func (tc *txContext) Value(key any) any {
-	return tc.base.Value(key)
+func (t *transaction) finish(ctx context.Context, committed bool) {
+	t.mu.Lock()
+
+	if t.done {
+		t.mu.Unlock()
+		return
+	}
+
+	t.done = true
+	onCommit, onRollback := t.onCommit, t.onRollback
+	t.onCommit, t.onRollback = nil, nil
+
+	t.mu.Unlock()
+
+	switch {
+	case committed && t.parent != nil:
+		t.parent.mu.Lock()
+		t.parent.onCommit = append(t.parent.onCommit, onCommit...)
+		t.parent.onRollback = append(t.parent.onRollback, onRollback...)
+		t.parent.mu.Unlock()
+	case committed:
+		runHooks(ctx, onCommit)
+	default:
+		runHooks(ctx, onRollback)
+	}
 }
 
-func getTxFromContext(ctx context.Context) (pgx.Tx, bool) {
-	if tc, ok := ctx.(*txContext); ok {
-		return tc.tx, true
+func runHooks(ctx context.Context, hooks []TxHook) {
+	for _, h := range hooks {
+		h(ctx)
 	}
+}
+
+func (t *transaction) Savepoint(ctx context.Context, name string) error {
+	_, err := t.tx.Exec(ctx, "SAVEPOINT "+pgx.Identifier{name}.Sanitize())
+
+	return err
+}
+
+func (t *transaction) RollbackTo(ctx context.Context, name string) error {
+	_, err := t.tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+pgx.Identifier{name}.Sanitize())
+
+	return err
+}
+
+func (t *transaction) ReleaseSavepoint(ctx context.Context, name string) error {
+	_, err := t.tx.Exec(ctx, "RELEASE SAVEPOINT "+pgx.Identifier{name}.Sanitize())
+
+	return err
+}
+
+func (t *transaction) ToPgx() pgx.Tx {
+	return t.tx
+}
+
+type txKey struct{}
+
+func withTx(ctx context.Context, tx *transaction) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+func getTransaction(ctx context.Context) (*transaction, bool) {
+	tx, ok := ctx.Value(txKey{}).(*transaction)
+
+	return tx, ok
+}
+
+// TxFromContext returns the transaction carried by ctx, if any.
+// The transaction survives any contexts derived from the one passed to TxFunc.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := getTransaction(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	return tx.tx, true
+}
+
+// InTx reports whether ctx carries a transaction.
+func InTx(ctx context.Context) bool {
+	_, ok := getTransaction(ctx)
 
-	return nil, false
+	return ok
 }
@@ -0,0 +1,157 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []TxOption
+		attempt  int
+		min, max time.Duration
+	}{
+		{"first", []TxOption{WithRetry(3, time.Second)}, 1, 500 * time.Millisecond, time.Second},
+		{"grows", []TxOption{WithRetry(3, time.Second)}, 3, 2 * time.Second, 4 * time.Second},
+		{"default cap", []TxOption{WithRetry(20, time.Second)}, 20, 15 * time.Second, 30 * time.Second},
+		{"custom cap", []TxOption{WithRetry(20, time.Second), WithRetryMaxBackoff(5 * time.Second)}, 10, 2500 * time.Millisecond, 5 * time.Second},
+		{"overflow", []TxOption{WithRetry(20, time.Duration(1<<60))}, 20, 15 * time.Second, 30 * time.Second},
+		{"no backoff", []TxOption{WithRetry(3, 0)}, 2, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := getTxOptions(tt.opts)
+
+			for range 100 {
+				d := opts.retry.delay(tt.attempt)
+				if d < tt.min || d > tt.max {
+					t.Fatalf("delay(%d) = %s, want between %s and %s", tt.attempt, d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+// fakeTx records the finishing calls of a transaction or savepoint.
+type fakeTx struct {
+	pgx.Tx
+	commitErr  error
+	committed  bool
+	rolledBack bool
+}
+
+func (t *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{}, nil
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	t.committed = true
+
+	return t.commitErr
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	if t.committed || t.rolledBack {
+		return pgx.ErrTxClosed
+	}
+
+	t.rolledBack = true
+
+	return nil
+}
+
+type hookLog []string
+
+func (l *hookLog) hook(name string) TxHook {
+	return func(ctx context.Context) {
+		*l = append(*l, name)
+	}
+}
+
+func TestTxHooks(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name      string
+		nestedErr error
+		commitErr error
+		want      []string
+	}{
+		{
+			name: "savepoint commit passes hooks to parent",
+			want: []string{"outer commit", "nested commit"},
+		},
+		{
+			name:      "savepoint rollback runs only its rollback hooks",
+			nestedErr: errFailed,
+			want:      []string{"nested rollback", "outer commit"},
+		},
+		{
+			name:      "failed outer commit runs rollback hooks",
+			commitErr: errFailed,
+			want:      []string{"outer rollback", "nested rollback"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &client{}
+			log := &hookLog{}
+
+			outer := &transaction{tx: &fakeTx{commitErr: tt.commitErr}}
+			ctx := withTx(context.Background(), outer)
+
+			_ = OnCommit(ctx, log.hook("outer commit"))
+			_ = OnRollback(ctx, log.hook("outer rollback"))
+
+			err := c.Transactional(ctx, func(ctx context.Context) error {
+				_ = OnCommit(ctx, log.hook("nested commit"))
+				_ = OnRollback(ctx, log.hook("nested rollback"))
+
+				return tt.nestedErr
+			})
+			if !errors.Is(err, tt.nestedErr) {
+				t.Fatalf("nested error = %v, want %v", err, tt.nestedErr)
+			}
+
+			if len(*log) != 0 && tt.nestedErr == nil {
+				t.Fatalf("hooks ran before the outer commit: %v", *log)
+			}
+
+			err = outer.Commit(ctx)
+			if !errors.Is(err, tt.commitErr) {
+				t.Fatalf("commit error = %v, want %v", err, tt.commitErr)
+			}
+
+			if !slices.Equal(*log, tt.want) {
+				t.Errorf("hooks = %v, want %v", *log, tt.want)
+			}
+		})
+	}
+}
+
+func TestTxHooksRunOnce(t *testing.T) {
+	log := &hookLog{}
+	tx := &transaction{tx: &fakeTx{}}
+	ctx := withTx(context.Background(), tx)
+
+	_ = OnRollback(ctx, log.hook("rollback"))
+
+	_ = tx.Rollback(ctx)
+	_ = tx.Rollback(ctx)
+
+	if !slices.Equal(*log, []string{"rollback"}) {
+		t.Errorf("hooks = %v, want one rollback", *log)
+	}
+
+	if err := OnCommit(context.Background(), log.hook("commit")); !errors.Is(err, ErrNoTx) {
+		t.Errorf("OnCommit without tx = %v, want ErrNoTx", err)
+	}
+}
@@ -0,0 +1,69 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// All runs the query and returns all rows mapped to T.
+func All[T any](ctx context.Context, c Client, sql string, args ...*Argument) ([]T, error) {
+	var result []T
+
+	err := c.Query(sql, &result).WithArgs(args...).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// One runs the query and returns the single row mapped to T.
+// It returns ErrNotFound if there are no rows and ErrTooManyRows if there are more than one.
+func One[T any](ctx context.Context, c Client, sql string, args ...*Argument) (T, error) {
+	var result T
+
+	err := c.Query(sql, &result).WithArgs(args...).Exec(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
+
+// Optional is like One, but returns nil instead of ErrNotFound.
+func Optional[T any](ctx context.Context, c Client, sql string, args ...*Argument) (*T, error) {
+	result, err := One[T](ctx, c, sql, args...)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+var errStopIter = errors.New("iteration stopped")
+
+// Iter runs the query and yields the rows mapped to T one by one.
+// Breaking out of the loop closes the rows. An error is yielded once as the last element.
+func Iter[T any](ctx context.Context, c Client, sql string, args ...*Argument) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var dest T
+
+		err := c.Query(sql, &dest).WithArgs(args...).Each(ctx, func() error {
+			if !yield(dest, nil) {
+				return errStopIter
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIter) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package pg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type valuesUser struct {
+	ID   int    `pg:"id"`
+	Name string `pg:"name"`
+}
+
+func newValuesCommand(sql string, users []valuesUser, args map[string]any) *command {
+	return &command{
+		client: &client{},
+		sql:    sql,
+		src:    reflect.ValueOf(users),
+		args:   args,
+	}
+}
+
+func TestValuesBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		args     map[string]any
+		want     string
+		wantArgs []any
+	}{
+		{
+			name:     "rows",
+			sql:      "INSERT INTO users (id, name) VALUES (@id, @name)",
+			want:     "INSERT INTO users (id, name) VALUES ($1, $2), ($3, $4)",
+			wantArgs: []any{1, "a", 2, "b"},
+		},
+		{
+			name:     "args shared across rows",
+			sql:      "INSERT INTO users (id, name, org) VALUES (@id, @name, #org) RETURNING #org AS org",
+			args:     map[string]any{"org": 7},
+			want:     "INSERT INTO users (id, name, org) VALUES ($1, $2, $3), ($4, $5, $3) RETURNING $3 AS org",
+			wantArgs: []any{1, "a", 7, 2, "b"},
+		},
+		{
+			name:     "arg before VALUES",
+			sql:      "INSERT INTO #table_name (id) VALUES (@id)",
+			args:     map[string]any{"table_name": "x"},
+			want:     "INSERT INTO $1 (id) VALUES ($2), ($3)",
+			wantArgs: []any{"x", 1, 2},
+		},
+		{
+			name:     "repeated model key in tuple",
+			sql:      "INSERT INTO users (id, parent) VALUES (@id, @id)",
+			want:     "INSERT INTO users (id, parent) VALUES ($1, $1), ($2, $2)",
+			wantArgs: []any{1, 2},
+		},
+		{
+			name:     "VALUES in subquery before the rows",
+			sql:      "WITH d AS (SELECT * FROM (VALUES (1)) v(x)) INSERT INTO users (id, name) VALUES (@id, @name)",
+			want:     "WITH d AS (SELECT * FROM (VALUES (1)) v(x)) INSERT INTO users (id, name) VALUES ($1, $2), ($3, $4)",
+			wantArgs: []any{1, "a", 2, "b"},
+		},
+		{
+			name:     "values in literal",
+			sql:      "INSERT INTO users (id, name) VALUES (@id, 'VALUES (x)')",
+			want:     "INSERT INTO users (id, name) VALUES ($1, 'VALUES (x)'), ($2, 'VALUES (x)')",
+			wantArgs: []any{1, 2},
+		},
+	}
+
+	users := []valuesUser{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := newValuesCommand(tt.sql, users, tt.args).build()
+			if err != nil {
+				t.Fatalf("build error: %v", err)
+			}
+
+			if len(statements) != 1 {
+				t.Fatalf("got %d statements, want 1", len(statements))
+			}
+
+			if statements[0].sql != tt.want {
+				t.Errorf("sql = %q, want %q", statements[0].sql, tt.want)
+			}
+
+			if !reflect.DeepEqual(statements[0].args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", statements[0].args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestValuesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		args map[string]any
+		err  error
+	}{
+		{name: "model key outside tuple", sql: "INSERT INTO users (id) VALUES (@id) RETURNING @name"},
+		{name: "model key before tuple", sql: "UPDATE users SET name = @name FROM (VALUES (@id)) v(id)"},
+		{name: "no VALUES", sql: "INSERT INTO users SELECT @id"},
+		{name: "missing arg", sql: "INSERT INTO users (id, org) VALUES (@id, #org)", err: &ArgNotFoundError{}},
+		{name: "missing field", sql: "INSERT INTO users (id) VALUES (@unknown)", err: &FieldNotFoundError{}},
+	}
+
+	users := []valuesUser{{ID: 1, Name: "a"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newValuesCommand(tt.sql, users, tt.args).build()
+			if err == nil {
+				t.Fatal("want error")
+			}
+
+			if tt.err != nil && reflect.TypeOf(err) != reflect.TypeOf(tt.err) {
+				t.Errorf("error = %T, want %T", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValuesChunks(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		args      map[string]any
+		rowParams int
+		argParams int
+	}{
+		{name: "model keys", sql: "INSERT INTO users (id, name) VALUES (@id, @name)", rowParams: 2},
+		{
+			name:      "model keys and args",
+			sql:       "INSERT INTO users (id, name, org) VALUES (@id, @name, #org) ON CONFLICT DO UPDATE SET tenant = #tenant",
+			args:      map[string]any{"org": 1, "tenant": 2},
+			rowParams: 2,
+			argParams: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := (maxParams - tt.argParams) / tt.rowParams
+			users := make([]valuesUser, 2*size+1)
+
+			statements, err := newValuesCommand(tt.sql, users, tt.args).build()
+			if err != nil {
+				t.Fatalf("build error: %v", err)
+			}
+
+			wantRows := []int{size, size, 1}
+			if len(statements) != len(wantRows) {
+				t.Fatalf("got %d statements, want %d", len(statements), len(wantRows))
+			}
+
+			for i, st := range statements {
+				params := wantRows[i]*tt.rowParams + tt.argParams
+
+				if len(st.args) != params {
+					t.Errorf("statement %d has %d args, want %d", i, len(st.args), params)
+				}
+
+				if len(st.args) > maxParams {
+					t.Errorf("statement %d has %d args, more than %d", i, len(st.args), maxParams)
+				}
+
+				last := fmt.Sprintf("$%d", params)
+				if !strings.Contains(st.sql, last) || strings.Contains(st.sql, fmt.Sprintf("$%d", params+1)) {
+					t.Errorf("statement %d must be numbered up to %s", i, last)
+				}
+			}
+		})
+	}
+}
+
+func TestValuesPreparedNames(t *testing.T) {
+	size := maxParams
+	cmd := newValuesCommand("INSERT INTO users (id) VALUES (@id)", make([]valuesUser, 2*size+3), nil)
+	cmd.name = "insert_users"
+
+	statements, err := cmd.build()
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+
+	want := []string{"insert_users", "insert_users", ""}
+	if len(statements) != len(want) {
+		t.Fatalf("got %d statements, want %d", len(statements), len(want))
+	}
+
+	for i, st := range statements {
+		if st.name != want[i] {
+			t.Errorf("statement %d name = %q, want %q", i, st.name, want[i])
+		}
+	}
+}
+
+func TestValuesPreparedNamesWithoutModelKeys(t *testing.T) {
+	for _, n := range []int{1, 2, 3} {
+		cmd := newValuesCommand("INSERT INTO users (id) VALUES (#id)", make([]valuesUser, n), map[string]any{"id": 1})
+		cmd.name = "insert_users"
+
+		statements, err := cmd.build()
+		if err != nil {
+			t.Fatalf("build error: %v", err)
+		}
+
+		for i, st := range statements {
+			if st.name != "" {
+				t.Errorf("%d models: statement %d name = %q, want unnamed", n, i, st.name)
+			}
+		}
+	}
+}